@@ -0,0 +1,142 @@
+package kriging
+
+import (
+	"math"
+	"strings"
+
+	vec2d "github.com/flywave/go3d/float64/vec2"
+	vec3d "github.com/flywave/go3d/float64/vec3"
+)
+
+type BreaklineType string
+
+const (
+	HardBreakline BreaklineType = "hard"
+	SoftBreakline BreaklineType = "soft"
+)
+
+const (
+	default_breakline_neighbours = 16
+	default_breakline_candidates = 4
+)
+
+type Breakline struct {
+	Type     BreaklineType
+	Vertices []vec3d.T
+}
+
+func breaklineType(v interface{}) (BreaklineType, bool) {
+	switch t := v.(type) {
+	case bool:
+		if t {
+			return HardBreakline, true
+		}
+	case string:
+		switch BreaklineType(strings.ToLower(strings.TrimSpace(t))) {
+		case HardBreakline, "true":
+			return HardBreakline, true
+		case SoftBreakline:
+			return SoftBreakline, true
+		}
+	}
+	return "", false
+}
+
+func densifyLine(vertices []vec3d.T, spacing float64) []vec3d.T {
+	if spacing <= 0 || len(vertices) < 2 {
+		return vertices
+	}
+	ret := []vec3d.T{vertices[0]}
+	for i := 1; i < len(vertices); i++ {
+		a, b := vertices[i-1], vertices[i]
+		steps := int(math.Ceil(metricDistance(vec2d.T{a[0], a[1]}, vec2d.T{b[0], b[1]}) / spacing))
+		for s := 1; s < steps; s++ {
+			t := float64(s) / float64(steps)
+			ret = append(ret, vec3d.T{a[0] + t*(b[0]-a[0]), a[1] + t*(b[1]-a[1]), a[2] + t*(b[2]-a[2])})
+		}
+		ret = append(ret, b)
+	}
+	return ret
+}
+
+type breaklineIndex struct {
+	segments [][2]vec2d.T
+	cellSize float64
+	cells    map[[2]int][]int
+}
+
+func newBreaklineIndex(lines []Breakline) *breaklineIndex {
+	bi := &breaklineIndex{cells: make(map[[2]int][]int)}
+	var length float64
+	for _, l := range lines {
+		if l.Type != HardBreakline {
+			continue
+		}
+		for i := 1; i < len(l.Vertices); i++ {
+			a, b := l.Vertices[i-1], l.Vertices[i]
+			bi.segments = append(bi.segments, [2]vec2d.T{{a[0], a[1]}, {b[0], b[1]}})
+			length += math.Hypot(b[0]-a[0], b[1]-a[1])
+		}
+	}
+	if len(bi.segments) == 0 {
+		return nil
+	}
+	bi.cellSize = length / float64(len(bi.segments))
+	if bi.cellSize <= 0 {
+		bi.cellSize = 1
+	}
+	for i, s := range bi.segments {
+		lo, hi := bi.cellRange(s[0], s[1])
+		for cx := lo[0]; cx <= hi[0]; cx++ {
+			for cy := lo[1]; cy <= hi[1]; cy++ {
+				bi.cells[[2]int{cx, cy}] = append(bi.cells[[2]int{cx, cy}], i)
+			}
+		}
+	}
+	return bi
+}
+
+func (bi *breaklineIndex) cellRange(a, b vec2d.T) ([2]int, [2]int) {
+	return [2]int{int(math.Floor(math.Min(a[0], b[0]) / bi.cellSize)), int(math.Floor(math.Min(a[1], b[1]) / bi.cellSize))},
+		[2]int{int(math.Floor(math.Max(a[0], b[0]) / bi.cellSize)), int(math.Floor(math.Max(a[1], b[1]) / bi.cellSize))}
+}
+
+func orient2(a, b, p vec2d.T) float64 {
+	return (b[0]-a[0])*(p[1]-a[1]) - (b[1]-a[1])*(p[0]-a[0])
+}
+
+func (bi *breaklineIndex) Blocked(a, b vec2d.T) bool {
+	if bi == nil {
+		return false
+	}
+	lo, hi := bi.cellRange(a, b)
+	for cx := lo[0]; cx <= hi[0]; cx++ {
+		for cy := lo[1]; cy <= hi[1]; cy++ {
+			for _, i := range bi.cells[[2]int{cx, cy}] {
+				c, d := bi.segments[i][0], bi.segments[i][1]
+				if orient2(c, d, a)*orient2(c, d, b) < 0 && orient2(a, b, c)*orient2(a, b, d) <= 0 {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func visibleNeighbours(idx *pointIndex, barrier *breaklineIndex, x, y float64, k int, radius float64) []neighbour {
+	if barrier == nil {
+		return idx.Nearest(x, y, k, radius)
+	}
+	p := vec2d.T{x, y}
+	found := make([]neighbour, 0, k)
+	for _, nb := range idx.Nearest(x, y, k*default_breakline_candidates, radius) {
+		if barrier.Blocked(p, vec2d.T{idx.pos[nb.index][0], idx.pos[nb.index][1]}) {
+			continue
+		}
+		found = append(found, nb)
+		if len(found) == k {
+			break
+		}
+	}
+	return found
+}
@@ -0,0 +1,83 @@
+package kriging
+
+import (
+	"testing"
+
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/general"
+
+	vec2d "github.com/flywave/go3d/float64/vec2"
+	vec3d "github.com/flywave/go3d/float64/vec3"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func plateauPoints() []vec3d.T {
+	pos := []vec3d.T{}
+	for i := 0; i <= 10; i++ {
+		for j := 0; j <= 10; j++ {
+			x, y := float64(i)*10, float64(j)*10
+			switch {
+			case y < 50:
+				pos = append(pos, vec3d.T{x, y, 0})
+			case y > 50:
+				pos = append(pos, vec3d.T{x, y, 10})
+			}
+		}
+	}
+	return pos
+}
+
+func TestBreaklines(t *testing.T) {
+	a := assert.New(t)
+
+	line := []vec3d.T{{-10, 50, 5}, {110, 50, 5}}
+	barrier := newBreaklineIndex([]Breakline{{Type: HardBreakline, Vertices: line}})
+	a.True(barrier.Blocked(vec2d.T{45, 45}, vec2d.T{45, 55}))
+	a.False(barrier.Blocked(vec2d.T{45, 45}, vec2d.T{55, 45}))
+	a.False(barrier.Blocked(vec2d.T{45, 45}, vec2d.T{45, 50}))
+	a.Nil(newBreaklineIndex([]Breakline{{Type: SoftBreakline, Vertices: line}}))
+
+	pos := plateauPoints()
+
+	idw, err := NewIDW(pos, IDWOptions{})
+	a.Nil(err)
+	a.True(idw.Predict(45, 48) > 1)
+	idw.barrier = barrier
+	a.Equal(0.0, idw.Predict(45, 48))
+	a.Equal(10.0, idw.Predict(45, 52))
+
+	kri := New(pos)
+	a.Nil(kri.fitVariogram(Exponential, 100))
+	bk := kri.localPredictor(default_breakline_neighbours, barrier)
+	a.True(bk.Predict(45, 48) < 2.5)
+	a.True(bk.Predict(45, 52) > 7.5)
+	a.InDelta(0, bk.Predict(40, 40), 1e-9)
+}
+
+func TestExtractBreaklines(t *testing.T) {
+	a := assert.New(t)
+
+	fc := geom.NewFeatureCollection()
+	line := geom.NewFeature(general.NewLineString3([][]float64{{0, 0, 1}, {10, 0, 2}}))
+	line.Properties["breakline"] = "hard"
+	fc.AddFeature(line)
+	fc.AddFeature(geom.NewFeature(general.NewLineString3([][]float64{{0, 10, 1}, {10, 10, 2}})))
+	fc.AddFeature(geom.NewFeature(general.NewPoint3([]float64{5, 5, 3})))
+
+	prop, spacing := "breakline", 600000.0
+	p := NewKrigingInterpolator(Options{Input: fc, BreaklineProperty: &prop, BreaklineSpacing: &spacing})
+	pos, _ := p.extractPosion()
+	a.Len(pos, 6)
+	a.Equal(vec3d.T{5, 0, 1.5}, pos[1])
+	a.Len(p.breaklines, 1)
+	a.Equal(HardBreakline, p.breaklines[0].Type)
+	a.Len(p.breaklines[0].Vertices, 3)
+	a.Len(densifyLine([]vec3d.T{{0, 60, 0}, {0.002, 60, 0}}, 50), 4)
+	a.Len(densifyLine([]vec3d.T{{0, 60, 0}, {0, 60.002, 0}}, 50), 6)
+
+	p.inputPos = pos
+	p.method = RBFMethod
+	_, err := p.computePredictor()
+	a.NotNil(err)
+}
@@ -0,0 +1,293 @@
+package kriging
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	vec2d "github.com/flywave/go3d/float64/vec2"
+	vec3d "github.com/flywave/go3d/float64/vec3"
+)
+
+type BoundaryType string
+
+const (
+	ConvexBoundary BoundaryType = "convex"
+	AlphaBoundary  BoundaryType = "alpha"
+	KNNBoundary    BoundaryType = "knn"
+)
+
+const (
+	default_alpha_scale         = 2
+	default_knn_hull_neighbours = 10
+)
+
+type Boundary interface {
+	Hull() []vec2d.T
+	Rect() vec2d.Rect
+	InHull(position vec3d.T, rotation Rotator, point vec2d.T) bool
+}
+
+type Concave struct {
+	hull []vec2d.T
+	mask maskPolygon
+}
+
+func newConcave(rings [][]vec2d.T) *Concave {
+	c := &Concave{mask: newMaskPolygon(rings)}
+	best := 0.0
+	for _, ring := range rings {
+		if area := polygonArea(ring); area > best {
+			best = area
+			c.hull = ring
+		}
+	}
+	return c
+}
+
+func NewAlphaShape(vertices []vec3d.T, alpha float64) (*Concave, error) {
+	d, err := NewDelaunay(vertices)
+	if err != nil {
+		return nil, err
+	}
+	if alpha <= 0 {
+		alpha = suggestCellSize(d.Vertices(), 1) * default_alpha_scale
+	}
+
+	keep := make([]bool, len(d.tris))
+	kept := 0
+	for t := range d.tris {
+		if d.alive[t] && d.real(t) {
+			if _, r := d.circumcircle(t); r <= alpha {
+				keep[t] = true
+				kept++
+			}
+		}
+	}
+	if kept == 0 {
+		return nil, errors.New("alpha is too small, no triangles remain")
+	}
+
+	next := make(map[int][]int)
+	for t := range d.tris {
+		if !keep[t] {
+			continue
+		}
+		for k := 0; k < 3; k++ {
+			if nb := d.adj[t][k]; nb >= 0 && keep[nb] {
+				continue
+			}
+			a, b := d.tris[t][(k+1)%3], d.tris[t][(k+2)%3]
+			next[a] = append(next[a], b)
+		}
+	}
+
+	rings := [][]vec2d.T{}
+	for len(next) > 0 {
+		var start int
+		for start = range next {
+			break
+		}
+		ring := []vec2d.T{}
+		for v := start; ; {
+			ring = append(ring, vec2d.T{d.pos[v][0], d.pos[v][1]})
+			ends := next[v]
+			if len(ends) == 0 {
+				break
+			}
+			w := ends[len(ends)-1]
+			if len(ends) == 1 {
+				delete(next, v)
+			} else {
+				next[v] = ends[:len(ends)-1]
+			}
+			v = w
+			if v == start {
+				break
+			}
+		}
+		rings = append(rings, ring)
+	}
+	return newConcave(rings), nil
+}
+
+func NewKNNHull(vertices []vec3d.T, k int) (*Concave, error) {
+	seen := make(map[vec2d.T]bool)
+	points := []vec2d.T{}
+	for i := range vertices {
+		p := vec2d.T{vertices[i][0], vertices[i][1]}
+		if !seen[p] {
+			seen[p] = true
+			points = append(points, p)
+		}
+	}
+	n := len(points)
+	if n < 3 {
+		return nil, errors.New("not enough points")
+	}
+	if k <= 0 {
+		k = default_knn_hull_neighbours
+	}
+	if k < 3 {
+		k = 3
+	}
+
+	pos := make([]vec3d.T, n)
+	for i := range points {
+		pos[i] = vec3d.T{points[i][0], points[i][1], 0}
+	}
+	idx := newPointIndex(pos, suggestCellSize(pos, 4))
+
+	for ; k < n; k++ {
+		if hull := knnHull(points, idx, k); hull != nil {
+			return newConcave([][]vec2d.T{hull}), nil
+		}
+	}
+	hull := NewConvex(pos).Hull()
+	if len(hull) < 3 || polygonArea(hull) == 0 {
+		return nil, errors.New("points are collinear")
+	}
+	return newConcave([][]vec2d.T{hull}), nil
+}
+
+func knnHull(points []vec2d.T, idx *pointIndex, k int) []vec2d.T {
+	n := len(points)
+	first := 0
+	for i := range points {
+		if points[i][1] < points[first][1] || (points[i][1] == points[first][1] && points[i][0] < points[first][0]) {
+			first = i
+		}
+	}
+
+	used := make([]bool, n)
+	used[first] = true
+	hull := []int{first}
+	current := first
+	back := math.Pi
+
+	for step := 2; (current != first || step == 2) && len(hull) <= n; step++ {
+		if step == 5 {
+			used[first] = false
+		}
+
+		var candidates []int
+		for q := k; ; q *= 2 {
+			candidates = candidates[:0]
+			for _, nb := range idx.Nearest(points[current][0], points[current][1], q, 0) {
+				if !used[nb.index] {
+					candidates = append(candidates, nb.index)
+				}
+			}
+			if len(candidates) >= k || q >= n {
+				break
+			}
+		}
+		if len(candidates) > k {
+			candidates = candidates[:k]
+		}
+		if len(candidates) == 0 {
+			break
+		}
+
+		angle := func(i int) float64 {
+			a := math.Atan2(points[i][1]-points[current][1], points[i][0]-points[current][0]) - back
+			for a <= 0 {
+				a += 2 * math.Pi
+			}
+			for a > 2*math.Pi {
+				a -= 2 * math.Pi
+			}
+			return a
+		}
+		sort.SliceStable(candidates, func(a, b int) bool {
+			return angle(candidates[a]) < angle(candidates[b])
+		})
+
+		chosen := -1
+		for _, c := range candidates {
+			if !knnCrosses(points, hull, current, c) {
+				chosen = c
+				break
+			}
+		}
+		if chosen < 0 {
+			return nil
+		}
+
+		back = math.Atan2(points[current][1]-points[chosen][1], points[current][0]-points[chosen][0])
+		current = chosen
+		used[chosen] = true
+		if chosen != first {
+			hull = append(hull, chosen)
+		}
+	}
+	if current != first && len(hull) < 3 {
+		return nil
+	}
+
+	ring := make([]vec2d.T, len(hull))
+	for i, h := range hull {
+		ring[i] = points[h]
+	}
+	if polygonArea(ring) <= 0 {
+		return nil
+	}
+	poly := newMaskPolygon([][]vec2d.T{ring})
+	for i := range points {
+		if !poly.Contains(points[i]) && !onRing(ring, points[i]) {
+			return nil
+		}
+	}
+	return ring
+}
+
+func knnCrosses(points []vec2d.T, hull []int, current, c int) bool {
+	for j := 0; j+1 < len(hull); j++ {
+		a, b := hull[j], hull[j+1]
+		if a == current || b == current || a == c || b == c {
+			continue
+		}
+		if segmentsIntersect(points[current], points[c], points[a], points[b]) {
+			return true
+		}
+	}
+	return false
+}
+
+func segmentsIntersect(a, b, c, d vec2d.T) bool {
+	o1, o2 := orient2(a, b, c), orient2(a, b, d)
+	o3, o4 := orient2(c, d, a), orient2(c, d, b)
+	if ((o1 > 0 && o2 < 0) || (o1 < 0 && o2 > 0)) && ((o3 > 0 && o4 < 0) || (o3 < 0 && o4 > 0)) {
+		return true
+	}
+	return (o1 == 0 && onSegment(a, b, c)) || (o2 == 0 && onSegment(a, b, d)) ||
+		(o3 == 0 && onSegment(c, d, a)) || (o4 == 0 && onSegment(c, d, b))
+}
+
+func onSegment(a, b, p vec2d.T) bool {
+	return math.Min(a[0], b[0]) <= p[0] && p[0] <= math.Max(a[0], b[0]) &&
+		math.Min(a[1], b[1]) <= p[1] && p[1] <= math.Max(a[1], b[1])
+}
+
+func onRing(ring []vec2d.T, p vec2d.T) bool {
+	for i := range ring {
+		a, b := ring[i], ring[(i+1)%len(ring)]
+		if orient2(a, b, p) == 0 && onSegment(a, b, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Concave) Hull() []vec2d.T {
+	return c.hull
+}
+
+func (c *Concave) Rect() vec2d.Rect {
+	return c.mask.rect
+}
+
+func (c *Concave) InHull(position vec3d.T, rotation Rotator, point vec2d.T) bool {
+	local := Rotator{-rotation.Degrees}.RotateVector(vec2d.T{point[0] - position[0], point[1] - position[1]})
+	return c.mask.Contains(local) || onRing(c.hull, local)
+}
@@ -0,0 +1,60 @@
+package kriging
+
+import (
+	"testing"
+
+	vec2d "github.com/flywave/go3d/float64/vec2"
+	vec3d "github.com/flywave/go3d/float64/vec3"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func lShapePoints() []vec3d.T {
+	pos := []vec3d.T{}
+	for i := 0; i <= 10; i++ {
+		for j := 0; j <= 10; j++ {
+			if i > 4 && j > 4 {
+				continue
+			}
+			pos = append(pos, vec3d.T{float64(i) * 10, float64(j) * 10, 1})
+		}
+	}
+	return pos
+}
+
+func TestConcave(t *testing.T) {
+	a := assert.New(t)
+
+	pos := lShapePoints()
+	convex := NewConvex(pos)
+	alpha, err := NewAlphaShape(pos, 10)
+	a.Nil(err)
+	knn, err := NewKNNHull(pos, 5)
+	a.Nil(err)
+
+	notch := vec2d.T{60, 60}
+	a.True(convex.InHull(vec3d.Zero, zRotator(), notch))
+	for _, b := range []Boundary{alpha, knn} {
+		a.False(b.InHull(vec3d.Zero, zRotator(), notch))
+		a.True(b.InHull(vec3d.Zero, zRotator(), vec2d.T{20, 80}))
+		a.True(b.InHull(vec3d.Zero, zRotator(), vec2d.T{80, 20}))
+		a.True(b.InHull(vec3d.Zero, zRotator(), vec2d.T{40, 40}))
+		a.False(b.InHull(vec3d.Zero, zRotator(), vec2d.T{-5, 50}))
+		a.InDelta(6400, polygonArea(b.Hull()), 50)
+
+		rect := b.Rect()
+		a.Equal(vec2d.T{0, 0}, rect.Min)
+		a.Equal(vec2d.T{100, 100}, rect.Max)
+	}
+
+	_, err = NewAlphaShape(pos, 1)
+	a.NotNil(err)
+	_, err = NewKNNHull([]vec3d.T{{0, 0, 0}, {1, 1, 0}}, 3)
+	a.NotNil(err)
+
+	p := NewKrigingInterpolator(Options{Boundary: &[]BoundaryType{AlphaBoundary}[0]})
+	p.inputPos = pos
+	a.Nil(p.computeBoundary())
+	a.False(p.inside(notch))
+	a.True(p.inside(vec2d.T{20, 20}))
+}
@@ -1,7 +1,9 @@
 package kriging
 
 import (
+	"errors"
 	"math"
+	"sort"
 
 	vec2d "github.com/flywave/go3d/float64/vec2"
 	vec3d "github.com/flywave/go3d/float64/vec3"
@@ -11,6 +13,7 @@ type Convex struct {
 	vertices []vec3d.T
 	hull     []vec2d.T
 	edges    []Edge
+	err      error
 }
 
 type Edge struct {
@@ -20,7 +23,7 @@ type Edge struct {
 }
 
 func NewConvex(vertices []vec3d.T) *Convex {
-	c := Convex{vertices, nil, nil}
+	c := Convex{vertices, nil, nil, nil}
 	return &c
 }
 
@@ -33,12 +36,16 @@ func (c *Convex) Rect() vec2d.Rect {
 }
 
 func (c *Convex) Hull() []vec2d.T {
+	hull, _ := c.Build()
+	return hull
+}
+
+func (c *Convex) Build() ([]vec2d.T, error) {
 	if c.hull == nil {
-		minX, maxX := c.getExtremePoints()
-		c.hull = append(c.quickHull(c.vertices, maxX, minX), c.quickHull(c.vertices, minX, maxX)...)
+		c.hull, c.err = monotoneChain(c.vertices)
 	}
 
-	return c.hull
+	return c.hull, c.err
 }
 
 func (c *Convex) Edges() []Edge {
@@ -79,24 +86,6 @@ func (c *Convex) Support(dir vec2d.T, rot Rotator) (bestVertex vec2d.T) {
 	return bestVertex
 }
 
-func (c *Convex) quickHull(points []vec3d.T, start, end vec2d.T) []vec2d.T {
-	pointDistanceIndicators := c.getLhsPointDistanceIndicatorMap(points, start, end)
-	if len(pointDistanceIndicators) == 0 {
-		return []vec2d.T{end}
-	}
-
-	farthestPoint := c.getFarthestPoint(pointDistanceIndicators)
-
-	newPoints := []vec3d.T{}
-	for point := range pointDistanceIndicators {
-		newPoints = append(newPoints, point)
-	}
-
-	return append(
-		c.quickHull(newPoints, farthestPoint, end),
-		c.quickHull(newPoints, start, farthestPoint)...)
-}
-
 func Subtract(lhs vec3d.T, rhs vec2d.T) vec2d.T {
 	return vec2d.T{lhs[0] - rhs[0], lhs[1] - rhs[1]}
 }
@@ -114,6 +103,9 @@ func OnTheRight(v vec2d.T, o vec2d.T) bool {
 }
 
 func (c *Convex) InHull(position vec3d.T, rotation Rotator, point vec2d.T) bool {
+	if len(c.Hull()) < 3 {
+		return false
+	}
 	for _, edge := range c.Edges() {
 		if !OnTheRight(Subtract2(point, Add(position, rotation.RotateVector(edge.Start))), Subtract2(Add(position, rotation.RotateVector(edge.End)), Add(position, rotation.RotateVector(edge.Start)))) {
 			return false
@@ -123,57 +115,55 @@ func (c *Convex) InHull(position vec3d.T, rotation Rotator, point vec2d.T) bool
 	return true
 }
 
-func (c *Convex) getExtremePoints() (minX, maxX vec2d.T) {
-	minX = vec2d.T{math.MaxFloat64, 0}
-	maxX = vec2d.T{-math.MaxFloat64, 0}
+func Cross(lhs, rhs vec2d.T) float64 {
+	return (lhs[0] * rhs[1]) - (lhs[1] * rhs[0])
+}
 
-	for _, p := range c.vertices {
-		if p[0] < minX[0] {
-			minX = vec2d.T{p[0], p[1]}
+func monotoneChain(vertices []vec3d.T) ([]vec2d.T, error) {
+	points := make([]vec2d.T, 0, len(vertices))
+	for i := range vertices {
+		if math.IsNaN(vertices[i][0]) || math.IsNaN(vertices[i][1]) {
+			continue
+		}
+		points = append(points, vec2d.T{vertices[i][0], vertices[i][1]})
+	}
+	sort.Slice(points, func(i, j int) bool {
+		if points[i][0] != points[j][0] {
+			return points[i][0] < points[j][0]
 		}
+		return points[i][1] < points[j][1]
+	})
 
-		if maxX[0] < p[0] {
-			maxX = vec2d.T{p[0], p[1]}
+	unique := points[:0]
+	for i := range points {
+		if i == 0 || points[i] != points[i-1] {
+			unique = append(unique, points[i])
 		}
 	}
+	points = unique
 
-	return minX, maxX
-}
-
-func (c *Convex) getLhsPointDistanceIndicatorMap(points []vec3d.T, start, end vec2d.T) map[vec3d.T]float64 {
-	pointDistanceIndicatorMap := make(map[vec3d.T]float64)
+	if len(points) < 3 {
+		return points, errors.New("convex hull needs at least three distinct points")
+	}
 
-	for _, point := range points {
-		distanceIndicator := c.getDistanceIndicator(point, start, end)
-		if distanceIndicator > 0 {
-			pointDistanceIndicatorMap[point] = distanceIndicator
+	hull := make([]vec2d.T, 0, 2*len(points))
+	for i := range points {
+		for len(hull) >= 2 && orientExact(hull[len(hull)-2], hull[len(hull)-1], points[i]) <= 0 {
+			hull = hull[:len(hull)-1]
 		}
+		hull = append(hull, points[i])
 	}
-
-	return pointDistanceIndicatorMap
-}
-
-func Cross(lhs, rhs vec2d.T) float64 {
-	return (lhs[0] * rhs[1]) - (lhs[1] * rhs[0])
-}
-
-func (c *Convex) getDistanceIndicator(point vec3d.T, start, end vec2d.T) float64 {
-	point2d := vec2d.T{point[0], point[1]}
-	vLine := vec2d.Sub(&end, &start)
-
-	vPoint := vec2d.Sub(&point2d, &start)
-
-	return Cross(vLine, vPoint)
-}
-
-func (c *Convex) getFarthestPoint(pointDistanceIndicatorMap map[vec3d.T]float64) (farthestPoint vec2d.T) {
-	maxDistanceIndicator := -math.MaxFloat64
-	for point, distanceIndicator := range pointDistanceIndicatorMap {
-		if maxDistanceIndicator < distanceIndicator {
-			maxDistanceIndicator = distanceIndicator
-			farthestPoint = vec2d.T{point[0], point[1]}
+	lower := len(hull) + 1
+	for i := len(points) - 2; i >= 0; i-- {
+		for len(hull) >= lower && orientExact(hull[len(hull)-2], hull[len(hull)-1], points[i]) <= 0 {
+			hull = hull[:len(hull)-1]
 		}
+		hull = append(hull, points[i])
 	}
+	hull = hull[:len(hull)-1]
 
-	return farthestPoint
+	if len(hull) < 3 {
+		return hull, errors.New("convex hull points are collinear")
+	}
+	return hull, nil
 }
@@ -1,6 +1,7 @@
 package kriging
 
 import (
+	"math"
 	"testing"
 
 	vec2d "github.com/flywave/go3d/float64/vec2"
@@ -70,3 +71,30 @@ func TestSupport(t *testing.T) {
 
 	a.Equal(c.Support(vec2d.T{1, 1}, zRotator()), vec2d.T{100, 100})
 }
+
+func TestConvexDegenerate(t *testing.T) {
+	a := assert.New(t)
+
+	vertices := []vec3d.T{{0, 0, 0}, {50, 0, 0}, {100, 0, 0}, {100, 100, 0}, {100, 100, 1}, {0, 100, 0}, {0, 50, 0}, {50, 50, 0}}
+	hull := []vec2d.T{{0, 0}, {100, 0}, {100, 100}, {0, 100}}
+	for i := 0; i < len(vertices); i++ {
+		rotated := append(append([]vec3d.T{}, vertices[i:]...), vertices[:i]...)
+		got, err := NewConvex(rotated).Build()
+		a.Nil(err)
+		a.Equal(hull, got)
+	}
+
+	_, err := NewConvex([]vec3d.T{{0, 0, 0}, {0, 0, 1}, {1, 1, 0}}).Build()
+	a.NotNil(err)
+
+	c := NewConvex([]vec3d.T{{0, 0, 0}, {1, 1, 0}, {2, 2, 0}, {3, 3, 0}})
+	got, err := c.Build()
+	a.NotNil(err)
+	a.Equal([]vec2d.T{{0, 0}, {3, 3}}, got)
+	a.False(c.InHull(vec3d.Zero, zRotator(), vec2d.T{1, 1}))
+	a.False(NewConvex(nil).InHull(vec3d.Zero, zRotator(), vec2d.T{0, 0}))
+
+	a.Equal(0.0, orientExact(vec2d.T{0.5, 0.5}, vec2d.T{12, 12}, vec2d.T{24, 24}))
+	a.True(orientExact(vec2d.T{0.5, 0.5}, vec2d.T{12, 12}, vec2d.T{24, math.Nextafter(24, 25)}) > 0)
+	a.True(orientExact(vec2d.T{0.5, 0.5}, vec2d.T{12, 12}, vec2d.T{24, math.Nextafter(24, 23)}) < 0)
+}
@@ -0,0 +1,189 @@
+package kriging
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flywave/go-geo"
+
+	vec2d "github.com/flywave/go3d/float64/vec2"
+	vec3d "github.com/flywave/go3d/float64/vec3"
+)
+
+const (
+	default_csv_delimiter = ','
+	default_csv_comment   = '#'
+)
+
+type CSVOptions struct {
+	Delimiter rune
+	Header    bool
+	X         string
+	Y         string
+	Value     string
+	Time      string
+	Srs       *string
+}
+
+type CSVReader struct {
+	r      *csv.Reader
+	proj   geo.Proj
+	cols   [3]int
+	time   int
+	row    int
+	report Report
+}
+
+func NewCSVReader(r io.Reader, opts CSVOptions) (*CSVReader, error) {
+	cr := csv.NewReader(r)
+	cr.Comma = opts.Delimiter
+	if cr.Comma == 0 {
+		cr.Comma = default_csv_delimiter
+	}
+	cr.Comment = default_csv_comment
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	cr.ReuseRecord = true
+
+	reader := &CSVReader{r: cr, time: -1}
+	if opts.Srs != nil {
+		reader.proj = geo.NewProj(*opts.Srs)
+	}
+
+	var header []string
+	if opts.Header {
+		rec, err := cr.Read()
+		if err != nil {
+			if err == io.EOF {
+				return nil, errors.New("csv header is missing")
+			}
+			return nil, err
+		}
+		header = make([]string, len(rec))
+		for i := range rec {
+			header[i] = strings.TrimSpace(rec[i])
+		}
+		reader.row++
+	}
+
+	specs := [3]string{opts.X, opts.Y, opts.Value}
+	for i, def := range [3]string{"x", "y", "z"} {
+		if specs[i] == "" {
+			if opts.Header {
+				specs[i] = def
+			} else {
+				specs[i] = strconv.Itoa(i)
+			}
+		}
+		col, err := csvColumn(header, specs[i])
+		if err != nil {
+			return nil, err
+		}
+		reader.cols[i] = col
+	}
+	if opts.Time != "" {
+		col, err := csvColumn(header, opts.Time)
+		if err != nil {
+			return nil, err
+		}
+		reader.time = col
+	}
+	return reader, nil
+}
+
+func csvColumn(header []string, spec string) (int, error) {
+	for i := range header {
+		if strings.EqualFold(header[i], spec) {
+			return i, nil
+		}
+	}
+	if i, err := strconv.Atoi(spec); err == nil && i >= 0 {
+		return i, nil
+	}
+	return 0, fmt.Errorf("csv column %q not found", spec)
+}
+
+func (r *CSVReader) Read() (Sample, error) {
+	for {
+		rec, err := r.r.Read()
+		if err != nil {
+			if _, ok := err.(*csv.ParseError); ok {
+				r.row++
+				r.report.Features++
+				r.report.skip(r.row, "malformed row")
+				continue
+			}
+			return Sample{}, err
+		}
+		r.row++
+		r.report.Features++
+
+		var v [3]float64
+		ok := true
+		for i, col := range r.cols {
+			if col >= len(rec) {
+				ok = false
+				break
+			}
+			if v[i], ok = propertyFloat(rec[col]); !ok {
+				break
+			}
+		}
+		if !ok {
+			r.report.skip(r.row, "non-numeric value")
+			continue
+		}
+
+		var ts time.Time
+		if r.time >= 0 {
+			t, ok := 0.0, false
+			if r.time < len(rec) {
+				t, ok = parseTime(strings.TrimSpace(rec[r.time]))
+			}
+			if !ok {
+				r.report.skip(r.row, "missing timestamp")
+				continue
+			}
+			ts = secondsTime(t)
+		}
+
+		if r.proj != nil && !r.proj.Eq(epsg4326) {
+			pos2 := r.proj.TransformTo(epsg4326, []vec2d.T{{v[0], v[1]}})
+			v[0], v[1] = pos2[0][0], pos2[0][1]
+		}
+		r.report.Points++
+		return Sample{X: v[0], Y: v[1], Value: v[2], Time: ts}, nil
+	}
+}
+
+func (r *CSVReader) Report() Report {
+	return r.report
+}
+
+func (p *KrigingInterpolator) readCSV() ([]vec3d.T, []float64, error) {
+	f, err := os.Open(*p.inputCSV)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	opts := p.csv
+	if opts.Time == "" && p.timeProperty != nil {
+		opts.Time = *p.timeProperty
+	}
+	reader, err := NewCSVReader(f, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if reader.proj == nil {
+		reader.proj = p.inputProj
+	}
+
+	return p.readSource(reader)
+}
@@ -0,0 +1,102 @@
+package kriging
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	vec3d "github.com/flywave/go3d/float64/vec3"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSVReader(t *testing.T) {
+	a := assert.New(t)
+
+	data := "id,lon,lat,pm25\n# comment\n1,10.5,20.25,7\n2,11,21,n/a\n3,12,22\n4, 13 ,23,\"9.5\"\n"
+	r, err := NewCSVReader(strings.NewReader(data), CSVOptions{Header: true, X: "LON", Y: "lat", Value: "pm25"})
+	a.Nil(err)
+
+	pos := []Sample{}
+	for {
+		p, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		a.Nil(err)
+		pos = append(pos, p)
+	}
+	a.Equal([]Sample{{X: 10.5, Y: 20.25, Value: 7}, {X: 13, Y: 23, Value: 9.5}}, pos)
+
+	report := r.Report()
+	a.Equal(4, report.Features)
+	a.Equal(2, report.Points)
+	a.Equal([]SkippedFeature{{3, "non-numeric value"}, {4, "non-numeric value"}}, report.Skipped)
+
+	r, err = NewCSVReader(strings.NewReader("1\t2\t3\t4\n"), CSVOptions{Delimiter: '\t', Value: "3"})
+	a.Nil(err)
+	p, err := r.Read()
+	a.Nil(err)
+	a.Equal(Sample{X: 1, Y: 2, Value: 4}, p)
+	_, err = r.Read()
+	a.Equal(io.EOF, err)
+
+	_, err = NewCSVReader(strings.NewReader("a,b,c\n"), CSVOptions{Header: true})
+	a.NotNil(err)
+	_, err = NewCSVReader(strings.NewReader(""), CSVOptions{Header: true})
+	a.NotNil(err)
+}
+
+func TestReadCSV(t *testing.T) {
+	a := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "points.csv")
+	a.Nil(os.WriteFile(path, []byte("x;y;z\n1;2;3\n4;5;6\n"), 0644))
+
+	p := NewKrigingInterpolator(Options{InputCSV: &path, CSV: &CSVOptions{Delimiter: ';', Header: true}})
+	pos, variance, err := p.readCSV()
+	a.Nil(err)
+	a.Nil(variance)
+	a.Equal([]vec3d.T{{1, 2, 3}, {4, 5, 6}}, pos)
+	a.Equal(2, p.Report().Points)
+
+	a.Nil(os.WriteFile(path, []byte("x,y,z\n111319.490793,5621521.486192,7\n"), 0644))
+	srs := "EPSG:3857"
+	p = NewKrigingInterpolator(Options{InputCSV: &path, CSV: &CSVOptions{Header: true, Srs: &srs}})
+	pos, _, err = p.readCSV()
+	a.Nil(err)
+	a.Len(pos, 1)
+	a.InDelta(1, pos[0][0], 1e-6)
+	a.InDelta(45, pos[0][1], 1e-6)
+	a.Equal(7.0, pos[0][2])
+}
+
+func TestReadTimedCSV(t *testing.T) {
+	a := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "series.csv")
+	a.Nil(os.WriteFile(path, []byte("x,y,z,t\n1,2,3,2021-03-04T05:00:00Z\n4,5,6,\n7,8,9,3600\n"), 0644))
+
+	prop := "t"
+	p := NewKrigingInterpolator(Options{InputCSV: &path, CSV: &CSVOptions{Header: true}, TimeProperty: &prop})
+	pos, _, err := p.readInput()
+	a.Nil(err)
+	a.Equal([]vec3d.T{{1, 2, 3}, {7, 8, 9}}, pos)
+	a.Equal([]float64{timeValue(time.Date(2021, 3, 4, 5, 0, 0, 0, time.UTC)), 3600}, p.inputTimes)
+	a.Equal([]SkippedFeature{{3, "missing timestamp"}}, p.Report().Skipped)
+
+	r, err := NewCSVReader(strings.NewReader("x,y,z,t\n1,2,3,2021-03-04\n"), CSVOptions{Header: true, Time: "t"})
+	a.Nil(err)
+	a.Equal([]Sample{{X: 1, Y: 2, Value: 3, Time: time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)}}, readSamples(a, r))
+
+	p = NewKrigingInterpolator(Options{Source: NewPositionSource(pos), TimeProperty: &prop, Timestamps: []time.Time{time.Unix(0, 0)}})
+	_, _, err = p.Process()
+	a.EqualError(err, "input has no timestamps")
+
+	mixed := NewSliceSource([]Sample{{X: 1, Time: time.Unix(10, 0)}, {X: 2}})
+	_, _, err = NewKrigingInterpolator(Options{Source: mixed}).readInput()
+	a.NotNil(err)
+}
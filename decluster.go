@@ -0,0 +1,139 @@
+package kriging
+
+import (
+	"math"
+
+	vec2d "github.com/flywave/go3d/float64/vec2"
+	vec3d "github.com/flywave/go3d/float64/vec3"
+)
+
+type DeclusterMethod string
+
+const (
+	CellDecluster      DeclusterMethod = "cell"
+	PolygonalDecluster DeclusterMethod = "polygonal"
+)
+
+const (
+	default_decluster_offsets    = 4
+	default_decluster_neighbours = 32
+)
+
+type Statistics struct {
+	Count    int
+	Min      float64
+	Max      float64
+	Mean     float64
+	Variance float64
+}
+
+func NewStatistics(pos []vec3d.T, weights []float64) Statistics {
+	st := Statistics{Count: len(pos), Min: math.Inf(1), Max: math.Inf(-1)}
+	if len(pos) == 0 {
+		return st
+	}
+	var wsum float64
+	for i := range pos {
+		w := 1.0
+		if weights != nil {
+			w = weights[i]
+		}
+		st.Min = math.Min(st.Min, pos[i][2])
+		st.Max = math.Max(st.Max, pos[i][2])
+		st.Mean += w * pos[i][2]
+		wsum += w
+	}
+	st.Mean /= wsum
+	for i := range pos {
+		w := 1.0
+		if weights != nil {
+			w = weights[i]
+		}
+		d := pos[i][2] - st.Mean
+		st.Variance += w * d * d
+	}
+	st.Variance /= wsum
+	return st
+}
+
+func Decluster(pos []vec3d.T, method DeclusterMethod, size float64) []float64 {
+	switch method {
+	case PolygonalDecluster:
+		return PolygonalWeights(pos)
+	default:
+		return CellWeights(pos, size)
+	}
+}
+
+func CellWeights(pos []vec3d.T, cellSize float64) []float64 {
+	n := len(pos)
+	weights := make([]float64, n)
+	if n == 0 {
+		return weights
+	}
+	if cellSize <= 0 {
+		cellSize = suggestCellSize(pos, 4)
+	}
+	min, _, _ := minMaxVec3(pos)
+
+	for o := 0; o < default_decluster_offsets; o++ {
+		offset := cellSize * float64(o) / default_decluster_offsets
+		counts := make(map[[2]int]int)
+		cells := make([][2]int, n)
+		for i := range pos {
+			cells[i] = [2]int{
+				int(math.Floor((pos[i][0] - min[0] + offset) / cellSize)),
+				int(math.Floor((pos[i][1] - min[1] + offset) / cellSize)),
+			}
+			counts[cells[i]]++
+		}
+		for i := range pos {
+			weights[i] += 1 / float64(counts[cells[i]]*len(counts))
+		}
+	}
+	return normaliseWeights(weights)
+}
+
+func PolygonalWeights(pos []vec3d.T) []float64 {
+	n := len(pos)
+	weights := make([]float64, n)
+	hull := NewConvex(pos).Hull()
+	if n < 3 || math.Abs(polygonArea(hull)) == 0 {
+		for i := range weights {
+			weights[i] = 1
+		}
+		return weights
+	}
+
+	idx := newPointIndex(pos, suggestCellSize(pos, 4))
+	for i := range pos {
+		cell := hull
+		a := vec2d.T{pos[i][0], pos[i][1]}
+		for _, nb := range idx.Nearest(pos[i][0], pos[i][1], default_decluster_neighbours+1, 0) {
+			if nb.index == i || nb.dist == 0 {
+				continue
+			}
+			cell = clipBisector(cell, a, vec2d.T{pos[nb.index][0], pos[nb.index][1]})
+		}
+		weights[i] = math.Abs(polygonArea(cell))
+	}
+	return normaliseWeights(weights)
+}
+
+func normaliseWeights(weights []float64) []float64 {
+	sum := 0.0
+	for _, w := range weights {
+		sum += w
+	}
+	if sum <= 0 {
+		for i := range weights {
+			weights[i] = 1
+		}
+		return weights
+	}
+	scale := float64(len(weights)) / sum
+	for i := range weights {
+		weights[i] *= scale
+	}
+	return weights
+}
@@ -0,0 +1,53 @@
+package kriging
+
+import (
+	"testing"
+
+	vec3d "github.com/flywave/go3d/float64/vec3"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func clusteredPoints() []vec3d.T {
+	pos := []vec3d.T{}
+	for i := 0; i < 5; i++ {
+		for j := 0; j < 5; j++ {
+			pos = append(pos, vec3d.T{float64(i) * 20, float64(j) * 20, 1})
+		}
+	}
+	for i := 0; i < 10; i++ {
+		pos = append(pos, vec3d.T{40 + float64(i)*0.5, 40 + float64(i%3)*0.5, 10})
+	}
+	return pos
+}
+
+func TestDeclusterWeights(t *testing.T) {
+	a := assert.New(t)
+
+	pos := clusteredPoints()
+
+	for _, method := range []DeclusterMethod{CellDecluster, PolygonalDecluster} {
+		w := Decluster(pos, method, 10)
+		a.Len(w, len(pos))
+
+		sum := 0.0
+		for _, v := range w {
+			sum += v
+		}
+		a.InDelta(float64(len(pos)), sum, 1e-9, method)
+		var grid, cluster float64
+		for i := range w {
+			if i < 25 {
+				grid += w[i] / 25
+			} else {
+				cluster += w[i] / 10
+			}
+		}
+		a.True(cluster < grid, method)
+
+		raw := NewStatistics(pos, nil)
+		declustered := NewStatistics(pos, w)
+		a.True(declustered.Mean < raw.Mean, method)
+		a.Equal(raw.Min, declustered.Min)
+	}
+}
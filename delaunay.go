@@ -0,0 +1,454 @@
+package kriging
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"sort"
+
+	"github.com/flywave/go-geom"
+
+	vec2d "github.com/flywave/go3d/float64/vec2"
+	vec3d "github.com/flywave/go3d/float64/vec3"
+)
+
+const (
+	default_super_triangle_scale = 20
+	default_barycentric_epsilon  = 1e-9
+	default_orient_epsilon       = 1e-15
+	default_incircle_epsilon     = 1e-14
+)
+
+type Triangle [3]int
+
+type Delaunay struct {
+	pos   []vec3d.T
+	n     int
+	tris  []Triangle
+	adj   [][3]int
+	alive []bool
+	last  int
+}
+
+func NewDelaunay(pos []vec3d.T) (*Delaunay, error) {
+	vertices := make([]vec3d.T, 0, len(pos)+3)
+	counts := []int{}
+	seen := make(map[[2]float64]int)
+	for i := range pos {
+		key := [2]float64{pos[i][0], pos[i][1]}
+		if j, ok := seen[key]; ok {
+			vertices[j][2] += pos[i][2]
+			counts[j]++
+			continue
+		}
+		seen[key] = len(vertices)
+		vertices = append(vertices, pos[i])
+		counts = append(counts, 1)
+	}
+	for i := range vertices {
+		vertices[i][2] /= float64(counts[i])
+	}
+
+	n := len(vertices)
+	if n < 3 {
+		return nil, errors.New("not enough points")
+	}
+	min, max, _ := minMaxVec3(vertices)
+	size := math.Max(max[0]-min[0], max[1]-min[1])
+	if size <= 0 {
+		return nil, errors.New("points are coincident")
+	}
+	cx, cy := (min[0]+max[0])/2, (min[1]+max[1])/2
+	s := size * default_super_triangle_scale
+	vertices = append(vertices,
+		vec3d.T{cx - s, cy - size},
+		vec3d.T{cx + s, cy - size},
+		vec3d.T{cx, cy + s},
+	)
+
+	d := &Delaunay{pos: vertices, n: n}
+	d.addTriangle(Triangle{n, n + 1, n + 2}, [3]int{-1, -1, -1})
+
+	cell := size / math.Sqrt(float64(n))
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		pa, pb := vertices[order[a]], vertices[order[b]]
+		ra, rb := math.Floor((pa[1]-min[1])/cell), math.Floor((pb[1]-min[1])/cell)
+		if ra != rb {
+			return ra < rb
+		}
+		if int(ra)%2 == 0 {
+			return pa[0] < pb[0]
+		}
+		return pa[0] > pb[0]
+	})
+	for _, i := range order {
+		d.insert(i)
+	}
+
+	if len(d.Triangles()) == 0 {
+		return nil, errors.New("points are collinear")
+	}
+	return d, nil
+}
+
+func (d *Delaunay) addTriangle(t Triangle, adj [3]int) int {
+	d.tris = append(d.tris, t)
+	d.adj = append(d.adj, adj)
+	d.alive = append(d.alive, true)
+	return len(d.tris) - 1
+}
+
+func orientExact(a, b, p vec2d.T) float64 {
+	l := (b[0] - a[0]) * (p[1] - a[1])
+	r := (b[1] - a[1]) * (p[0] - a[0])
+	det := l - r
+	if math.Abs(det) > default_orient_epsilon*(math.Abs(l)+math.Abs(r)) {
+		return det
+	}
+
+	rat := func(v float64) *big.Rat {
+		return new(big.Rat).SetFloat64(v)
+	}
+	sub := func(x, y float64) *big.Rat {
+		return new(big.Rat).Sub(rat(x), rat(y))
+	}
+	el := new(big.Rat).Mul(sub(b[0], a[0]), sub(p[1], a[1]))
+	er := new(big.Rat).Mul(sub(b[1], a[1]), sub(p[0], a[0]))
+	return float64(el.Cmp(er))
+}
+
+func inCircleExact(a, b, c, p vec2d.T) float64 {
+	adx, ady := a[0]-p[0], a[1]-p[1]
+	bdx, bdy := b[0]-p[0], b[1]-p[1]
+	cdx, cdy := c[0]-p[0], c[1]-p[1]
+	alift, blift, clift := adx*adx+ady*ady, bdx*bdx+bdy*bdy, cdx*cdx+cdy*cdy
+	det := alift*(bdx*cdy-cdx*bdy) - blift*(adx*cdy-cdx*ady) + clift*(adx*bdy-bdx*ady)
+	permanent := alift*(math.Abs(bdx*cdy)+math.Abs(cdx*bdy)) +
+		blift*(math.Abs(adx*cdy)+math.Abs(cdx*ady)) +
+		clift*(math.Abs(adx*bdy)+math.Abs(bdx*ady))
+	if math.Abs(det) > default_incircle_epsilon*permanent {
+		return det
+	}
+
+	sub := func(x, y float64) *big.Rat {
+		return new(big.Rat).Sub(new(big.Rat).SetFloat64(x), new(big.Rat).SetFloat64(y))
+	}
+	lift := func(x, y *big.Rat) *big.Rat {
+		return new(big.Rat).Add(new(big.Rat).Mul(x, x), new(big.Rat).Mul(y, y))
+	}
+	cross := func(x0, y0, x1, y1 *big.Rat) *big.Rat {
+		return new(big.Rat).Sub(new(big.Rat).Mul(x0, y1), new(big.Rat).Mul(x1, y0))
+	}
+	ax, ay := sub(a[0], p[0]), sub(a[1], p[1])
+	bx, by := sub(b[0], p[0]), sub(b[1], p[1])
+	cx, cy := sub(c[0], p[0]), sub(c[1], p[1])
+	exact := new(big.Rat).Mul(lift(ax, ay), cross(bx, by, cx, cy))
+	exact.Sub(exact, new(big.Rat).Mul(lift(bx, by), cross(ax, ay, cx, cy)))
+	exact.Add(exact, new(big.Rat).Mul(lift(cx, cy), cross(ax, ay, bx, by)))
+	return float64(exact.Sign())
+}
+
+func (d *Delaunay) area(a, b int, x, y float64) float64 {
+	pa, pb := d.pos[a], d.pos[b]
+	return (pb[0]-pa[0])*(y-pa[1]) - (pb[1]-pa[1])*(x-pa[0])
+}
+
+func (d *Delaunay) orient(a, b int, x, y float64) float64 {
+	pa, pb := d.pos[a], d.pos[b]
+	return orientExact(vec2d.T{pa[0], pa[1]}, vec2d.T{pb[0], pb[1]}, vec2d.T{x, y})
+}
+
+func (d *Delaunay) inCircle(t int, x, y float64) bool {
+	tri := d.tris[t]
+	super := -1
+	for k := 0; k < 3; k++ {
+		if tri[k] >= d.n {
+			if super >= 0 {
+				super = -1
+				break
+			}
+			super = k
+		}
+	}
+	if super >= 0 {
+		a, b := tri[(super+1)%3], tri[(super+2)%3]
+		o := d.orient(a, b, x, y)
+		if o != 0 {
+			return o > 0
+		}
+		pa, pb := d.pos[a], d.pos[b]
+		return (x-pa[0])*(x-pb[0])+(y-pa[1])*(y-pb[1]) < 0
+	}
+
+	a, b, c := d.pos[d.tris[t][0]], d.pos[d.tris[t][1]], d.pos[d.tris[t][2]]
+	return inCircleExact(vec2d.T{a[0], a[1]}, vec2d.T{b[0], b[1]}, vec2d.T{c[0], c[1]}, vec2d.T{x, y}) > 0
+}
+
+func (d *Delaunay) circumcircle(t int) (vec2d.T, float64) {
+	a, b, c := d.pos[d.tris[t][0]], d.pos[d.tris[t][1]], d.pos[d.tris[t][2]]
+	bx, by := b[0]-a[0], b[1]-a[1]
+	cx, cy := c[0]-a[0], c[1]-a[1]
+	det := 2 * (bx*cy - by*cx)
+	ux := (cy*(bx*bx+by*by) - by*(cx*cx+cy*cy)) / det
+	uy := (bx*(cx*cx+cy*cy) - cx*(bx*bx+by*by)) / det
+	return vec2d.T{a[0] + ux, a[1] + uy}, math.Hypot(ux, uy)
+}
+
+func (d *Delaunay) locate(x, y float64) int {
+	t := d.last
+	if t >= len(d.tris) || !d.alive[t] {
+		for t = len(d.tris) - 1; t > 0 && !d.alive[t]; t-- {
+		}
+	}
+	for steps := 0; steps < len(d.tris); steps++ {
+		moved := false
+		for e := 0; e < 3; e++ {
+			k := (e + steps) % 3
+			a, b := d.tris[t][(k+1)%3], d.tris[t][(k+2)%3]
+			if d.orient(a, b, x, y) < 0 && d.adj[t][k] >= 0 {
+				t = d.adj[t][k]
+				moved = true
+				break
+			}
+		}
+		if !moved {
+			d.last = t
+			return t
+		}
+	}
+	for i := range d.tris {
+		if d.alive[i] && d.contains(i, x, y) {
+			d.last = i
+			return i
+		}
+	}
+	return t
+}
+
+func (d *Delaunay) contains(t int, x, y float64) bool {
+	for k := 0; k < 3; k++ {
+		if d.orient(d.tris[t][(k+1)%3], d.tris[t][(k+2)%3], x, y) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *Delaunay) cavity(t int, x, y float64) []int {
+	in := map[int]bool{t: true}
+	cavity := []int{t}
+	for i := 0; i < len(cavity); i++ {
+		for _, nb := range d.adj[cavity[i]] {
+			if nb >= 0 && !in[nb] && d.inCircle(nb, x, y) {
+				in[nb] = true
+				cavity = append(cavity, nb)
+			}
+		}
+	}
+	return cavity
+}
+
+func (d *Delaunay) insert(i int) {
+	x, y := d.pos[i][0], d.pos[i][1]
+	cavity := d.cavity(d.locate(x, y), x, y)
+	in := make(map[int]bool, len(cavity))
+	for _, c := range cavity {
+		in[c] = true
+	}
+
+	byFirst := make(map[int]int)
+	bySecond := make(map[int]int)
+	created := []int{}
+	for _, c := range cavity {
+		for k := 0; k < 3; k++ {
+			nb := d.adj[c][k]
+			if nb >= 0 && in[nb] {
+				continue
+			}
+			a, b := d.tris[c][(k+1)%3], d.tris[c][(k+2)%3]
+			t := d.addTriangle(Triangle{a, b, i}, [3]int{-1, -1, nb})
+			if nb >= 0 {
+				for j := 0; j < 3; j++ {
+					if d.adj[nb][j] == c {
+						d.adj[nb][j] = t
+					}
+				}
+			}
+			byFirst[a] = t
+			bySecond[b] = t
+			created = append(created, t)
+		}
+	}
+	for _, t := range created {
+		a, b := d.tris[t][0], d.tris[t][1]
+		d.adj[t][0] = byFirst[b]
+		d.adj[t][1] = bySecond[a]
+	}
+	for _, c := range cavity {
+		d.alive[c] = false
+	}
+	d.last = created[0]
+}
+
+func (d *Delaunay) real(t int) bool {
+	return d.tris[t][0] < d.n && d.tris[t][1] < d.n && d.tris[t][2] < d.n
+}
+
+func (d *Delaunay) Vertices() []vec3d.T {
+	return d.pos[:d.n]
+}
+
+func (d *Delaunay) Triangles() []Triangle {
+	ret := []Triangle{}
+	for t := range d.tris {
+		if d.alive[t] && d.real(t) {
+			ret = append(ret, d.tris[t])
+		}
+	}
+	return ret
+}
+
+func (d *Delaunay) barycentric(t int, x, y float64) ([3]float64, bool) {
+	a, b, c := d.tris[t][0], d.tris[t][1], d.tris[t][2]
+	area := d.area(a, b, d.pos[c][0], d.pos[c][1])
+	if area == 0 {
+		return [3]float64{}, false
+	}
+	l := [3]float64{
+		d.area(b, c, x, y) / area,
+		d.area(c, a, x, y) / area,
+		d.area(a, b, x, y) / area,
+	}
+	for k := range l {
+		if l[k] < -default_barycentric_epsilon {
+			return l, false
+		}
+	}
+	return l, true
+}
+
+func (d *Delaunay) interpolate(t int, l [3]float64) float64 {
+	return l[0]*d.pos[d.tris[t][0]][2] + l[1]*d.pos[d.tris[t][1]][2] + l[2]*d.pos[d.tris[t][2]][2]
+}
+
+func (d *Delaunay) Predict(x, y float64) float64 {
+	t := d.locate(x, y)
+	candidates := append([]int{t}, d.adj[t][:]...)
+	for _, c := range candidates {
+		if c < 0 || !d.real(c) {
+			continue
+		}
+		if l, ok := d.barycentric(c, x, y); ok {
+			return d.interpolate(c, l)
+		}
+	}
+	return math.NaN()
+}
+
+type NaturalNeighbour struct {
+	*Delaunay
+}
+
+func NewNaturalNeighbour(pos []vec3d.T) (*NaturalNeighbour, error) {
+	d, err := NewDelaunay(pos)
+	if err != nil {
+		return nil, err
+	}
+	return &NaturalNeighbour{d}, nil
+}
+
+func (nn *NaturalNeighbour) Predict(x, y float64) float64 {
+	d := nn.Delaunay
+	cavity := d.cavity(d.locate(x, y), x, y)
+
+	box := vec2d.Rect{Min: vec2d.MaxVal, Max: vec2d.MinVal}
+	seen := make(map[int]bool)
+	vertices := []int{}
+	for _, t := range cavity {
+		if !d.real(t) {
+			return d.Predict(x, y)
+		}
+		centre, r := d.circumcircle(t)
+		box.Extend(&vec2d.T{centre[0] - r, centre[1] - r})
+		box.Extend(&vec2d.T{centre[0] + r, centre[1] + r})
+		for _, v := range d.tris[t] {
+			if !seen[v] {
+				seen[v] = true
+				vertices = append(vertices, v)
+			}
+		}
+	}
+
+	p := vec2d.T{x, y}
+	cell := []vec2d.T{box.Min, {box.Max[0], box.Min[1]}, box.Max, {box.Min[0], box.Max[1]}}
+	for _, v := range vertices {
+		if d.pos[v][0] == x && d.pos[v][1] == y {
+			return d.pos[v][2]
+		}
+		cell = clipBisector(cell, p, vec2d.T{d.pos[v][0], d.pos[v][1]})
+	}
+
+	var sum, total float64
+	for _, v := range vertices {
+		a := vec2d.T{d.pos[v][0], d.pos[v][1]}
+		part := cell
+		for _, u := range vertices {
+			if u != v {
+				part = clipBisector(part, a, vec2d.T{d.pos[u][0], d.pos[u][1]})
+			}
+		}
+		area := math.Abs(polygonArea(part))
+		sum += area * d.pos[v][2]
+		total += area
+	}
+	if total == 0 {
+		return d.Predict(x, y)
+	}
+	return sum / total
+}
+
+func (d *Delaunay) FeatureCollection() *geom.FeatureCollection {
+	fc := geom.NewFeatureCollection()
+	for i, t := range d.Triangles() {
+		ring := make([][]float64, 0, 4)
+		for _, v := range []int{t[0], t[1], t[2], t[0]} {
+			ring = append(ring, []float64{d.pos[v][0], d.pos[v][1], d.pos[v][2]})
+		}
+		f := geom.NewPolygonFeature([][][]float64{ring})
+		f.Properties["index"] = i
+		fc.AddFeature(f)
+	}
+	return fc
+}
+
+func (d *Delaunay) WriteGeoJSON(w io.Writer) error {
+	data, err := json.Marshal(d.FeatureCollection())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func (d *Delaunay) WriteOBJ(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, v := range d.Vertices() {
+		if _, err := fmt.Fprintf(bw, "v %.10g %.10g %.10g\n", v[0], v[1], v[2]); err != nil {
+			return err
+		}
+	}
+	for _, t := range d.Triangles() {
+		if _, err := fmt.Fprintf(bw, "f %d %d %d\n", t[0]+1, t[1]+1, t[2]+1); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
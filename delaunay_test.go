@@ -0,0 +1,123 @@
+package kriging
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	vec2d "github.com/flywave/go3d/float64/vec2"
+	vec3d "github.com/flywave/go3d/float64/vec3"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func randomPoints(n int, seed int64) []vec3d.T {
+	rng := rand.New(rand.NewSource(seed))
+	pos := make([]vec3d.T, n)
+	for i := range pos {
+		x, y := rng.Float64()*100, rng.Float64()*100
+		pos[i] = vec3d.T{x, y, 3 + 0.5*x - 0.25*y}
+	}
+	return pos
+}
+
+func TestDelaunay(t *testing.T) {
+	a := assert.New(t)
+
+	pos := randomPoints(200, 1)
+	d, err := NewDelaunay(pos)
+	a.Nil(err)
+
+	tris := d.Triangles()
+	a.Equal(2*len(pos)-2-len(NewConvex(pos).Hull()), len(tris))
+	for i, tri := range d.tris {
+		if !d.alive[i] || !d.real(i) {
+			continue
+		}
+		a.True(d.orient(tri[0], tri[1], d.pos[tri[2]][0], d.pos[tri[2]][1]) > 0)
+		centre, r := d.circumcircle(i)
+		for _, p := range pos {
+			a.False(math.Hypot(p[0]-centre[0], p[1]-centre[1]) < r*(1-1e-9))
+		}
+	}
+
+	nn, err := NewNaturalNeighbour(pos)
+	a.Nil(err)
+	for _, p := range [][2]float64{{50, 50}, {20, 70}, {63.3, 41.7}} {
+		want := 3 + 0.5*p[0] - 0.25*p[1]
+		a.InDelta(want, d.Predict(p[0], p[1]), 1e-9)
+		a.InDelta(want, nn.Predict(p[0], p[1]), 1e-9)
+	}
+	for _, i := range []int{0, 17, 99} {
+		a.InDelta(pos[i][2], d.Predict(pos[i][0], pos[i][1]), 1e-9)
+		a.Equal(pos[i][2], nn.Predict(pos[i][0], pos[i][1]))
+	}
+	a.True(math.IsNaN(d.Predict(-10, 50)))
+	a.True(math.IsNaN(nn.Predict(150, 150)))
+
+	_, err = NewDelaunay([]vec3d.T{{0, 0, 1}, {1, 1, 1}, {2, 2, 1}})
+	a.NotNil(err)
+}
+
+func TestDelaunayPredicates(t *testing.T) {
+	a := assert.New(t)
+
+	for _, off := range []vec2d.T{{116.3, 39.9}, {123456.7, 7654321.1}, {0.1, 0.3}} {
+		for s := 1e-7; s < 1; s *= 3.7 {
+			p0, p1 := vec2d.T{off[0], off[1]}, vec2d.T{off[0] + s, off[1] + s}
+			a.Equal(0.0, inCircleExact(p0, vec2d.T{p1[0], p0[1]}, p1, vec2d.T{p0[0], p1[1]}))
+			a.True(inCircleExact(p0, vec2d.T{p1[0], p0[1]}, p1, vec2d.T{p0[0] + s/2, p1[1] - s/2}) > 0)
+		}
+	}
+
+	pos := []vec3d.T{}
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 8; j++ {
+			pos = append(pos, vec3d.T{116.3 + float64(i)*1e-4, 39.9 + float64(j)*1e-4, float64(i + j)})
+		}
+	}
+	d, err := NewDelaunay(pos)
+	a.Nil(err)
+	tris := d.Triangles()
+	a.Len(tris, 2*7*7)
+	for _, tri := range tris {
+		a.True(d.orient(tri[0], tri[1], d.pos[tri[2]][0], d.pos[tri[2]][1]) > 0)
+		for _, p := range pos {
+			a.False(inCircleExact(vec2d.T{d.pos[tri[0]][0], d.pos[tri[0]][1]}, vec2d.T{d.pos[tri[1]][0], d.pos[tri[1]][1]},
+				vec2d.T{d.pos[tri[2]][0], d.pos[tri[2]][1]}, vec2d.T{p[0], p[1]}) > 0)
+		}
+	}
+}
+
+func TestDelaunayExport(t *testing.T) {
+	a := assert.New(t)
+
+	d, err := NewDelaunay([]vec3d.T{{0, 0, 1}, {10, 0, 2}, {10, 10, 3}, {0, 10, 4}, {5, 5, 5}})
+	a.Nil(err)
+	a.Len(d.Triangles(), 4)
+
+	var obj bytes.Buffer
+	a.Nil(d.WriteOBJ(&obj))
+	a.Equal(5, strings.Count(obj.String(), "v "))
+	a.Equal(4, strings.Count(obj.String(), "f "))
+
+	var buf bytes.Buffer
+	a.Nil(d.WriteGeoJSON(&buf))
+	var fc struct {
+		Type     string
+		Features []struct {
+			Geometry struct {
+				Type        string
+				Coordinates [][][]float64
+			}
+		}
+	}
+	a.Nil(json.Unmarshal(buf.Bytes(), &fc))
+	a.Equal("FeatureCollection", fc.Type)
+	a.Len(fc.Features, 4)
+	a.Equal("Polygon", fc.Features[0].Geometry.Type)
+	a.Len(fc.Features[0].Geometry.Coordinates[0], 4)
+}
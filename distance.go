@@ -0,0 +1,101 @@
+package kriging
+
+import (
+	"math"
+
+	vec2d "github.com/flywave/go3d/float64/vec2"
+	vec3d "github.com/flywave/go3d/float64/vec3"
+)
+
+const (
+	default_metres_per_degree = 111320
+	default_min_cos_latitude  = 1e-6
+)
+
+func metresToDegrees(metres, lat float64) (float64, float64) {
+	c := math.Max(math.Cos(lat*math.Pi/180), default_min_cos_latitude)
+	return metres / (default_metres_per_degree * c), metres / default_metres_per_degree
+}
+
+func metricDistance(a, b vec2d.T) float64 {
+	c := math.Cos((a[1] + b[1]) / 2 * math.Pi / 180)
+	return math.Hypot((a[0]-b[0])*c, a[1]-b[1]) * default_metres_per_degree
+}
+
+func metricSegmentDistance(p, a, b vec2d.T) float64 {
+	c := math.Cos(p[1] * math.Pi / 180)
+	ax, ay := (a[0]-p[0])*c, a[1]-p[1]
+	bx, by := (b[0]-p[0])*c, b[1]-p[1]
+	dx, dy := bx-ax, by-ay
+	t := 0.0
+	if l := dx*dx + dy*dy; l > 0 {
+		t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/l))
+	}
+	return math.Hypot(ax+t*dx, ay+t*dy) * default_metres_per_degree
+}
+
+type distanceMask struct {
+	index    *pointIndex
+	distance float64
+}
+
+func newDistanceMask(pos []vec3d.T, distance float64) *distanceMask {
+	return &distanceMask{index: newPointIndex(pos, suggestCellSize(pos, 4)), distance: distance}
+}
+
+func (m *distanceMask) Contains(p vec2d.T) bool {
+	if m == nil {
+		return true
+	}
+	radius, _ := metresToDegrees(m.distance, p[1])
+	for _, nb := range m.index.Within(p[0], p[1], radius) {
+		q := m.index.pos[nb.index]
+		if metricDistance(p, vec2d.T{q[0], q[1]}) <= m.distance {
+			return true
+		}
+	}
+	return false
+}
+
+type bufferedBoundary struct {
+	Boundary
+	rings    [][]vec2d.T
+	distance float64
+}
+
+func newBufferedBoundary(b Boundary, distance float64) *bufferedBoundary {
+	rings := [][]vec2d.T{b.Hull()}
+	if c, ok := b.(*Concave); ok {
+		rings = c.mask.rings
+	}
+	return &bufferedBoundary{Boundary: b, rings: rings, distance: distance}
+}
+
+func (b *bufferedBoundary) Rect() vec2d.Rect {
+	rect := b.Boundary.Rect()
+	if b.distance <= 0 {
+		return rect
+	}
+	_, dy := metresToDegrees(b.distance, 0)
+	lat := math.Min(90, math.Max(math.Abs(rect.Min[1]), math.Abs(rect.Max[1]))+dy)
+	dx, _ := metresToDegrees(b.distance, lat)
+	return vec2d.Rect{
+		Min: vec2d.T{rect.Min[0] - dx, rect.Min[1] - dy},
+		Max: vec2d.T{rect.Max[0] + dx, rect.Max[1] + dy},
+	}
+}
+
+func (b *bufferedBoundary) InHull(position vec3d.T, rotation Rotator, point vec2d.T) bool {
+	in := b.Boundary.InHull(position, rotation, point)
+	if in == (b.distance > 0) {
+		return in
+	}
+	local := Rotator{-rotation.Degrees}.RotateVector(vec2d.T{point[0] - position[0], point[1] - position[1]})
+	d := math.Inf(1)
+	for _, ring := range b.rings {
+		for i := range ring {
+			d = math.Min(d, metricSegmentDistance(local, ring[i], ring[(i+1)%len(ring)]))
+		}
+	}
+	return d <= math.Abs(b.distance) == (b.distance > 0)
+}
@@ -0,0 +1,49 @@
+package kriging
+
+import (
+	"testing"
+
+	vec2d "github.com/flywave/go3d/float64/vec2"
+	vec3d "github.com/flywave/go3d/float64/vec3"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistanceMask(t *testing.T) {
+	a := assert.New(t)
+
+	a.InDelta(1113.2, metricDistance(vec2d.T{0, 0}, vec2d.T{0.01, 0}), 1e-6)
+	a.InDelta(556.6, metricDistance(vec2d.T{0, 60}, vec2d.T{0.01, 60}), 1e-6)
+	a.InDelta(1113.2, metricSegmentDistance(vec2d.T{0.5, 0.01}, vec2d.T{0, 0}, vec2d.T{1, 0}), 1e-6)
+
+	pos := []vec3d.T{{0, 0, 1}, {0.1, 0, 1}, {0.1, 0.1, 1}, {0, 0.1, 1}}
+	maxDistance, buffer := 2000.0, 1000.0
+	p := NewKrigingInterpolator(Options{MaxDistance: &maxDistance, HullBuffer: &buffer})
+	p.inputPos = pos
+	a.Nil(p.computeBoundary())
+	p.computeMask()
+
+	a.True(p.inside(vec2d.T{0.01, 0.01}))
+	a.False(p.inside(vec2d.T{0.05, 0.05}))
+	a.True(p.inside(vec2d.T{-0.005, 0.005}))
+	a.False(p.inside(vec2d.T{-0.02, 0.005}))
+
+	rect := p.boundary.Rect()
+	a.InDelta(-0.00898, rect.Min[0], 1e-5)
+	a.InDelta(0.10898, rect.Max[1], 1e-5)
+
+	buffer = -1000
+	p = NewKrigingInterpolator(Options{HullBuffer: &buffer})
+	p.inputPos = pos
+	a.Nil(p.computeBoundary())
+	p.computeMask()
+	a.True(p.inside(vec2d.T{0.05, 0.05}))
+	a.False(p.inside(vec2d.T{0.005, 0.05}))
+	a.False(p.inside(vec2d.T{-0.005, 0.05}))
+	a.Equal(p.convexHull.Rect(), p.boundary.Rect())
+
+	grid := &Grid{Coordinates: Coordinates{{0.05, 0.05, 0}, {0.005, 0.05, 0}}}
+	a.Nil(p.resample(grid, func(x, y float64) float64 { return 1 }))
+	a.Equal(1.0, grid.Coordinates[0][2])
+	a.Equal(default_no_data, grid.Coordinates[1][2])
+}
@@ -0,0 +1,145 @@
+package kriging
+
+import (
+	"fmt"
+
+	vec2d "github.com/flywave/go3d/float64/vec2"
+	vec3d "github.com/flywave/go3d/float64/vec3"
+)
+
+type DuplicatePolicy string
+
+const (
+	AverageDuplicates DuplicatePolicy = "average"
+	FirstDuplicate    DuplicatePolicy = "first"
+	LastDuplicate     DuplicatePolicy = "last"
+	MinDuplicate      DuplicatePolicy = "min"
+	MaxDuplicate      DuplicatePolicy = "max"
+	ErrorDuplicates   DuplicatePolicy = "error"
+)
+
+type DuplicateOptions struct {
+	Policy    DuplicatePolicy
+	Tolerance float64
+}
+
+type DuplicateGroup struct {
+	Indices  []int
+	Position vec3d.T
+}
+
+type DuplicateError struct {
+	Indices []int
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("coincident points %v", e.Indices)
+}
+
+func duplicateGroups(pos []vec3d.T, tolerance float64) [][]int {
+	groups := [][]int{}
+	if tolerance <= 0 {
+		seen := make(map[[2]float64]int)
+		for i, p := range pos {
+			key := [2]float64{p[0], p[1]}
+			if g, ok := seen[key]; ok {
+				groups[g] = append(groups[g], i)
+				continue
+			}
+			seen[key] = len(groups)
+			groups = append(groups, []int{i})
+		}
+		return groups
+	}
+
+	idx := newPointIndex(pos, suggestCellSize(pos, 4))
+	assigned := make([]bool, len(pos))
+	for i, p := range pos {
+		if assigned[i] {
+			continue
+		}
+		radius, _ := metresToDegrees(tolerance, p[1])
+
+		group := []int{i}
+		assigned[i] = true
+		for _, n := range idx.Within(p[0], p[1], radius) {
+			j := n.index
+			if assigned[j] || metricDistance(vec2d.T{p[0], p[1]}, vec2d.T{pos[j][0], pos[j][1]}) > tolerance {
+				continue
+			}
+			assigned[j] = true
+			group = append(group, j)
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+func mergeDuplicate(pos []vec3d.T, group []int, policy DuplicatePolicy) (vec3d.T, int) {
+	switch policy {
+	case FirstDuplicate:
+		return pos[group[0]], group[0]
+	case LastDuplicate:
+		last := group[len(group)-1]
+		return pos[last], last
+	case MinDuplicate, MaxDuplicate:
+		best := group[0]
+		for _, i := range group[1:] {
+			if (policy == MinDuplicate && pos[i][2] < pos[best][2]) || (policy == MaxDuplicate && pos[i][2] > pos[best][2]) {
+				best = i
+			}
+		}
+		return pos[best], best
+	}
+	return meanVec3(pos, group), -1
+}
+
+func (p *KrigingInterpolator) mergeDuplicates(pos []vec3d.T, variance []float64) ([]vec3d.T, []float64, error) {
+	groups := duplicateGroups(pos, p.duplicates.Tolerance)
+	if len(groups) == len(pos) {
+		return pos, variance, nil
+	}
+
+	newPos := make([]vec3d.T, 0, len(groups))
+	newIndex := make([]int, 0, len(groups))
+	var newVar, newWeights []float64
+	for _, g := range groups {
+		if len(g) > 1 && p.duplicates.Policy == ErrorDuplicates {
+			return nil, nil, &DuplicateError{Indices: g}
+		}
+		v, i := mergeDuplicate(pos, g, p.duplicates.Policy)
+		if len(g) > 1 {
+			p.report.Duplicates = append(p.report.Duplicates, DuplicateGroup{Indices: g, Position: v})
+		}
+		newPos = append(newPos, v)
+		newIndex = append(newIndex, g[0])
+
+		if variance != nil {
+			if i >= 0 {
+				newVar = append(newVar, variance[i])
+			} else {
+				sum := 0.0
+				for _, j := range g {
+					sum += variance[j]
+				}
+				newVar = append(newVar, sum/float64(len(g)*len(g)))
+			}
+		}
+		if p.inputWeights != nil {
+			if i >= 0 {
+				newWeights = append(newWeights, p.inputWeights[i])
+			} else {
+				sum := 0.0
+				for _, j := range g {
+					sum += p.inputWeights[j]
+				}
+				newWeights = append(newWeights, sum)
+			}
+		}
+	}
+	if p.inputWeights != nil {
+		p.inputWeights = newWeights
+	}
+	p.inputIndex = newIndex
+	return newPos, newVar, nil
+}
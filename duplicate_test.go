@@ -0,0 +1,53 @@
+package kriging
+
+import (
+	"testing"
+
+	vec3d "github.com/flywave/go3d/float64/vec3"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDuplicateGroups(t *testing.T) {
+	a := assert.New(t)
+
+	pos := []vec3d.T{{10, 45, 1}, {10.1, 45, 2}, {10, 45, 3}, {10.00001, 45.00001, 4}}
+	a.Equal([][]int{{0, 2}, {1}, {3}}, duplicateGroups(pos, 0))
+	a.Equal([][]int{{0, 2, 3}, {1}}, duplicateGroups(pos, 2))
+	a.Equal([][]int{{0, 2}, {1}, {3}}, duplicateGroups(pos, 1))
+}
+
+func TestMergeDuplicates(t *testing.T) {
+	a := assert.New(t)
+
+	pos := []vec3d.T{{10, 45, 1}, {10.1, 45, 2}, {10, 45, 3}, {10, 45, 8}}
+	expect := map[DuplicatePolicy]float64{
+		AverageDuplicates: 4,
+		FirstDuplicate:    1,
+		LastDuplicate:     8,
+		MinDuplicate:      1,
+		MaxDuplicate:      8,
+	}
+	for policy, v := range expect {
+		p := NewKrigingInterpolator(Options{Duplicates: &DuplicateOptions{Policy: policy}})
+		res, variance, err := p.mergeDuplicates(append([]vec3d.T{}, pos...), []float64{3, 1, 3, 3})
+		a.Nil(err)
+		a.Equal([]vec3d.T{{10, 45, v}, {10.1, 45, 2}}, res, string(policy))
+		a.Len(variance, 2)
+		a.Equal([]DuplicateGroup{{Indices: []int{0, 2, 3}, Position: vec3d.T{10, 45, v}}}, p.Report().Duplicates)
+		a.Equal([]int{0, 1}, p.inputIndex)
+	}
+
+	p := NewKrigingInterpolator(Options{Duplicates: &DuplicateOptions{Policy: AverageDuplicates}})
+	p.inputWeights = []float64{1, 2, 3, 4}
+	_, variance, err := p.mergeDuplicates(pos, []float64{3, 1, 3, 3})
+	a.Nil(err)
+	a.Equal([]float64{1, 1}, variance)
+	a.Equal([]float64{8, 2}, p.inputWeights)
+
+	p = NewKrigingInterpolator(Options{Duplicates: &DuplicateOptions{Policy: ErrorDuplicates}})
+	_, _, err = p.mergeDuplicates(pos, nil)
+	a.Equal(&DuplicateError{Indices: []int{0, 2, 3}}, err)
+	_, _, err = p.mergeDuplicates(pos[:2], nil)
+	a.Nil(err)
+}
@@ -0,0 +1,206 @@
+package kriging
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/general"
+
+	vec3d "github.com/flywave/go3d/float64/vec3"
+)
+
+const default_geojson_record_separator = 0x1e
+
+type recordSeparatorReader struct {
+	r io.Reader
+}
+
+func (r recordSeparatorReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	for i := 0; i < n; i++ {
+		if b[i] == default_geojson_record_separator {
+			b[i] = '\n'
+		}
+	}
+	return n, err
+}
+
+func geojsonFeature(raw []byte) (*geom.Feature, error) {
+	fea, err := general.UnmarshalFeature(raw)
+	if err == nil {
+		return fea, nil
+	}
+	var empty struct {
+		Geometry   json.RawMessage        `json:"geometry"`
+		Properties map[string]interface{} `json:"properties"`
+	}
+	if json.Unmarshal(raw, &empty) != nil || (len(empty.Geometry) > 0 && string(empty.Geometry) != "null") {
+		return nil, err
+	}
+	return &geom.Feature{Properties: empty.Properties}, nil
+}
+
+type GeoJSONReader struct {
+	dec     *json.Decoder
+	inArray bool
+	index   int
+	src     *FeatureSource
+}
+
+func NewGeoJSONReader(r io.Reader, opts FeatureSourceOptions) *GeoJSONReader {
+	return &GeoJSONReader{
+		dec: json.NewDecoder(recordSeparatorReader{bufio.NewReader(r)}),
+		src: NewFeatureSource(geom.NewFeatureCollection(), opts),
+	}
+}
+
+func (r *GeoJSONReader) delim(want json.Delim) error {
+	t, err := r.dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := t.(json.Delim); !ok || d != want {
+		return fmt.Errorf("geojson: expected %q, got %v", want, t)
+	}
+	return nil
+}
+
+func (r *GeoJSONReader) skipObject() error {
+	for r.dec.More() {
+		if _, err := r.dec.Token(); err != nil {
+			return err
+		}
+		var skip json.RawMessage
+		if err := r.dec.Decode(&skip); err != nil {
+			return err
+		}
+	}
+	return r.delim('}')
+}
+
+func (r *GeoJSONReader) Feature() (*geom.Feature, error) {
+	for {
+		if r.inArray {
+			if r.dec.More() {
+				var raw json.RawMessage
+				if err := r.dec.Decode(&raw); err != nil {
+					return nil, err
+				}
+				return geojsonFeature(raw)
+			}
+			r.inArray = false
+			if err := r.delim(']'); err != nil {
+				return nil, err
+			}
+			if err := r.skipObject(); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := r.delim('{'); err != nil {
+			return nil, err
+		}
+		members := map[string]json.RawMessage{}
+		for r.dec.More() {
+			t, err := r.dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ := t.(string)
+			if key == "features" {
+				if err := r.delim('['); err != nil {
+					return nil, err
+				}
+				r.inArray = true
+				break
+			}
+			var value json.RawMessage
+			if err := r.dec.Decode(&value); err != nil {
+				return nil, err
+			}
+			members[key] = value
+		}
+		if r.inArray {
+			continue
+		}
+		if err := r.delim('}'); err != nil {
+			return nil, err
+		}
+
+		var kind string
+		json.Unmarshal(members["type"], &kind)
+		if kind != "Feature" {
+			return nil, fmt.Errorf("geojson: unsupported object type %q", kind)
+		}
+		raw, err := json.Marshal(members)
+		if err != nil {
+			return nil, err
+		}
+		return geojsonFeature(raw)
+	}
+}
+
+func (r *GeoJSONReader) Read() (Sample, error) {
+	for len(r.src.pending) == 0 {
+		fea, err := r.Feature()
+		if err != nil {
+			return Sample{}, err
+		}
+		r.src.report.Features++
+		if fea.Geometry == nil {
+			r.src.report.skip(r.index, "missing geometry")
+		} else {
+			r.src.push(r.index, fea)
+		}
+		r.index++
+	}
+	return r.src.pop(), nil
+}
+
+func (r *GeoJSONReader) Report() Report {
+	return r.src.Report()
+}
+
+func ReadGeoJSON(path string) (*geom.FeatureCollection, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := NewGeoJSONReader(f, FeatureSourceOptions{})
+	fc := geom.NewFeatureCollection()
+	for {
+		fea, err := reader.Feature()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if fea.Geometry != nil {
+			fc.AddFeature(fea)
+		}
+	}
+	return fc, nil
+}
+
+func (p *KrigingInterpolator) readGeoJSON() ([]vec3d.T, []float64, error) {
+	f, err := os.Open(*p.inputGeoJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	reader := NewGeoJSONReader(f, FeatureSourceOptions{ValueProperty: p.valueProperty, VarianceProperty: p.varianceProperty, TimeProperty: p.timeProperty})
+	reader.src.proj = p.inputProj
+	reader.src.breakline = p.extractBreaklines
+
+	pos, variance, err := p.readSource(reader)
+	p.breaklines = reader.src.lines
+	return pos, variance, err
+}
@@ -0,0 +1,99 @@
+package kriging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	vec3d "github.com/flywave/go3d/float64/vec3"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeoJSONReader(t *testing.T) {
+	a := assert.New(t)
+
+	collection := `{"type": "FeatureCollection", "name": "pts", "features": [
+		{"type": "Feature", "properties": {"v": 5}, "geometry": {"type": "Point", "coordinates": [1, 2, 3]}},
+		{"type": "Feature", "properties": {"v": 6}, "geometry": null},
+		{"type": "Feature", "properties": {"v": "n/a"}, "geometry": {"type": "Point", "coordinates": [3, 4]}},
+		{"type": "Feature", "properties": {"v": 7}, "geometry": {"type": "LineString", "coordinates": [[5, 6], [7, 8]]}}
+	], "bbox": [1, 2, 7, 8]}`
+
+	prop := "v"
+	r := NewGeoJSONReader(strings.NewReader(collection), FeatureSourceOptions{ValueProperty: &prop})
+	a.Equal([]Sample{{X: 1, Y: 2, Value: 5}, {X: 5, Y: 6, Value: 7}, {X: 7, Y: 8, Value: 7}}, readSamples(a, r))
+	report := r.Report()
+	a.Equal(4, report.Features)
+	a.Equal(3, report.Points)
+	a.Equal([]SkippedFeature{{1, "missing geometry"}, {2, "non-numeric value"}}, report.Skipped)
+
+	seq := "\x1e{\"geometry\": {\"type\": \"Point\", \"coordinates\": [1, 1, 9]}, \"type\": \"Feature\", \"properties\": {}}\n" +
+		"\x1e{\"type\": \"Feature\", \"properties\": {}, \"geometry\": {\"type\": \"MultiPoint\", \"coordinates\": [[2, 2, 8], [3, 3, 7]]}}\n"
+	r = NewGeoJSONReader(strings.NewReader(seq), FeatureSourceOptions{})
+	a.Equal([]Sample{{X: 1, Y: 1, Value: 9}, {X: 2, Y: 2, Value: 8}, {X: 3, Y: 3, Value: 7}}, readSamples(a, r))
+
+	r = NewGeoJSONReader(strings.NewReader(`{"type": "Point", "coordinates": [1, 2]}`), FeatureSourceOptions{})
+	_, err := r.Read()
+	a.NotNil(err)
+	r = NewGeoJSONReader(strings.NewReader(`{"type": "FeatureCollection", "features": [{"type": "Feature"`), FeatureSourceOptions{})
+	_, err = r.Read()
+	a.NotNil(err)
+	a.NotEqual(io.EOF, err)
+}
+
+func TestReadGeoJSON(t *testing.T) {
+	a := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "points.geojsonl")
+	a.Nil(os.WriteFile(path, []byte(
+		`{"type": "Feature", "properties": {"s": 0.5}, "geometry": {"type": "Point", "coordinates": [10, 20, 30]}}`+"\n"+
+			`{"type": "Feature", "properties": {"s": 0.5}, "geometry": {"type": "Point", "coordinates": [11, 21, 31]}}`+"\n"), 0644))
+
+	prop := "s"
+	p := NewKrigingInterpolator(Options{InputGeoJSON: &path, VarianceProperty: &prop})
+	pos, variance, err := p.readInput()
+	a.Nil(err)
+	a.Equal([]vec3d.T{{10, 20, 30}, {11, 21, 31}}, pos)
+	a.Equal([]float64{0.5, 0.5}, variance)
+	a.Equal(2, p.Report().Points)
+
+	fc, err := ReadGeoJSON(path)
+	a.Nil(err)
+	a.Len(fc.Features, 2)
+}
+
+func TestGeoJSONInterpolation(t *testing.T) {
+	a := assert.New(t)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "points.geojson")
+	features := []string{}
+	for h := 0; h < 3; h++ {
+		for _, p := range krigingPoints() {
+			features = append(features, fmt.Sprintf(`{"type": "Feature", "properties": {"t": "2021-03-04T0%d:00:00Z"}, "geometry": {"type": "Point", "coordinates": [%g, %g, %g]}}`, h, p[0]/1000, p[1]/1000, p[2]+float64(h)))
+		}
+	}
+	a.Nil(os.WriteFile(path, []byte(`{"type": "FeatureCollection", "features": [`+strings.Join(features, ",")+`]}`), 0644))
+
+	method, ps := IDWMethod, [2]float64{0.001, 0.001}
+	p := NewKrigingInterpolator(Options{InputGeoJSON: &path, Output: filepath.Join(dir, "out.tif"), Method: &method, PixelSize: &ps})
+	rect, _, err := p.Process()
+	a.Nil(err)
+	a.Len(p.inputPos, len(features))
+	a.InDelta(0.07, rect.Max[0]-rect.Min[0], 0.002)
+	a.Nil(p.input)
+
+	prop := "t"
+	ts := []time.Time{time.Date(2021, 3, 4, 1, 30, 0, 0, time.UTC)}
+	p = NewKrigingInterpolator(Options{InputGeoJSON: &path, Output: filepath.Join(dir, "st.tif"), PixelSize: &ps, TimeProperty: &prop, Timestamps: ts})
+	_, _, err = p.Process()
+	a.Nil(err)
+	a.Nil(p.input)
+	a.Len(p.inputTimes, len(features))
+	a.Equal(timeValue(ts[0])+1800, p.inputTimes[len(features)-1])
+}
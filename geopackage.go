@@ -0,0 +1,256 @@
+package kriging
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/flywave/go-geom"
+)
+
+const (
+	default_wkb_z    = 0x80000000
+	default_wkb_m    = 0x40000000
+	default_wkb_srid = 0x20000000
+)
+
+type wkbReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *wkbReader) uint32(order binary.ByteOrder) (uint32, error) {
+	if r.pos+4 > len(r.data) {
+		return 0, errors.New("truncated wkb")
+	}
+	v := order.Uint32(r.data[r.pos:])
+	r.pos += 4
+	return v, nil
+}
+
+func (r *wkbReader) coords(order binary.ByteOrder, dims int, hasZ bool) ([]float64, error) {
+	if r.pos+8*dims > len(r.data) {
+		return nil, errors.New("truncated wkb")
+	}
+	c := make([]float64, dims)
+	for i := range c {
+		c[i] = math.Float64frombits(order.Uint64(r.data[r.pos+8*i:]))
+	}
+	r.pos += 8 * dims
+	if hasZ {
+		return []float64{c[0], c[1], c[2]}, nil
+	}
+	return []float64{c[0], c[1]}, nil
+}
+
+func (r *wkbReader) points(order binary.ByteOrder, dims int, hasZ bool) ([][]float64, error) {
+	n, err := r.uint32(order)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([][]float64, 0, n)
+	for i := 0; i < int(n); i++ {
+		c, err := r.coords(order, dims, hasZ)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, c)
+	}
+	return ret, nil
+}
+
+func (r *wkbReader) rings(order binary.ByteOrder, dims int, hasZ bool) ([][][]float64, error) {
+	n, err := r.uint32(order)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([][][]float64, 0, n)
+	for i := 0; i < int(n); i++ {
+		pts, err := r.points(order, dims, hasZ)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, pts)
+	}
+	return ret, nil
+}
+
+func (r *wkbReader) header() (binary.ByteOrder, int, int, bool, error) {
+	if r.pos >= len(r.data) {
+		return nil, 0, 0, false, errors.New("truncated wkb")
+	}
+	var order binary.ByteOrder = binary.BigEndian
+	if r.data[r.pos] == 1 {
+		order = binary.LittleEndian
+	}
+	r.pos++
+	t, err := r.uint32(order)
+	if err != nil {
+		return nil, 0, 0, false, err
+	}
+	hasZ, hasM := t&default_wkb_z != 0, t&default_wkb_m != 0
+	if t&default_wkb_srid != 0 {
+		if _, err := r.uint32(order); err != nil {
+			return nil, 0, 0, false, err
+		}
+	}
+	t &^= default_wkb_z | default_wkb_m | default_wkb_srid
+	switch t / 1000 {
+	case 1:
+		hasZ = true
+	case 2:
+		hasM = true
+	case 3:
+		hasZ, hasM = true, true
+	}
+	dims := 2
+	if hasZ {
+		dims++
+	}
+	if hasM {
+		dims++
+	}
+	return order, int(t % 1000), dims, hasZ, nil
+}
+
+func (r *wkbReader) geometries() ([]geom.Geometry, error) {
+	order, kind, dims, hasZ, err := r.header()
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case 1:
+		c, err := r.coords(order, dims, hasZ)
+		if err != nil {
+			return nil, err
+		}
+		if math.IsNaN(c[0]) || math.IsNaN(c[1]) {
+			return nil, nil
+		}
+		return []geom.Geometry{pointGeometry(c, hasZ)}, nil
+	case 2:
+		pts, err := r.points(order, dims, hasZ)
+		if err != nil || len(pts) == 0 {
+			return nil, err
+		}
+		return []geom.Geometry{lineGeometry([][][]float64{pts}, hasZ)}, nil
+	case 3:
+		rings, err := r.rings(order, dims, hasZ)
+		if err != nil || len(rings) == 0 {
+			return nil, err
+		}
+		return []geom.Geometry{polygonGeometry(rings, hasZ)}, nil
+	case 4, 5, 6, 7:
+		n, err := r.uint32(order)
+		if err != nil {
+			return nil, err
+		}
+		var parts []geom.Geometry
+		for i := 0; i < int(n); i++ {
+			sub, err := r.geometries()
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, sub...)
+		}
+		return parts, nil
+	}
+	return nil, fmt.Errorf("unsupported wkb geometry type %d", kind)
+}
+
+func gpkgGeometries(blob []byte) ([]geom.Geometry, error) {
+	if len(blob) < 8 || blob[0] != 'G' || blob[1] != 'P' {
+		return nil, errors.New("invalid geopackage geometry")
+	}
+	flags := blob[3]
+	if flags&0x10 != 0 {
+		return nil, nil
+	}
+	envelope := [...]int{0, 32, 48, 48, 64}
+	e := int(flags>>1) & 0x07
+	if e >= len(envelope) {
+		return nil, errors.New("invalid geopackage envelope")
+	}
+	r := &wkbReader{data: blob, pos: 8 + envelope[e]}
+	return r.geometries()
+}
+
+func ReadGeoPackage(path, layer string) (*geom.FeatureCollection, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+
+	db, err := openSQLite(f)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var column string
+	var srsID int64
+	err = db.rows("gpkg_geometry_columns", func(row map[string]interface{}) error {
+		name, _ := row["table_name"].(string)
+		if column != "" || (layer != "" && !strings.EqualFold(name, layer)) {
+			return nil
+		}
+		layer = name
+		column, _ = row["column_name"].(string)
+		srsID, _ = row["srs_id"].(int64)
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if column == "" {
+		return nil, "", fmt.Errorf("geopackage layer %q not found", layer)
+	}
+
+	srs := ""
+	err = db.rows("gpkg_spatial_ref_sys", func(row map[string]interface{}) error {
+		if id, _ := row["srs_id"].(int64); id != srsID {
+			return nil
+		}
+		org, _ := row["organization"].(string)
+		code, _ := row["organization_coordsys_id"].(int64)
+		if strings.EqualFold(org, "EPSG") && code > 0 {
+			srs = fmt.Sprintf("EPSG:%d", code)
+		} else if def, ok := row["definition"].(string); ok && !strings.EqualFold(def, "undefined") {
+			srs = strings.TrimSpace(def)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	fc := geom.NewFeatureCollection()
+	err = db.rows(layer, func(row map[string]interface{}) error {
+		blob, ok := row[column].([]byte)
+		if !ok {
+			return nil
+		}
+		geoms, err := gpkgGeometries(blob)
+		if err != nil {
+			return err
+		}
+		for _, g := range geoms {
+			fea := geom.NewFeature(g)
+			for k, v := range row {
+				if k != column {
+					fea.Properties[k] = v
+				}
+			}
+			fc.AddFeature(fea)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return fc, srs, nil
+}
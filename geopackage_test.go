@@ -0,0 +1,35 @@
+package kriging
+
+import (
+	"testing"
+
+	"github.com/flywave/go-geom/general"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadGeoPackage(t *testing.T) {
+	a := assert.New(t)
+
+	fc, srs, err := ReadGeoPackage("testdata/points.gpkg", "")
+	a.Nil(err)
+	a.Equal("EPSG:32650", srs)
+	a.Len(fc.Features, 300)
+
+	fea := fc.Features[150]
+	a.Equal([]float64{150, 300, 3}, fea.Geometry.(*general.Point3).Data())
+	a.Equal(75.0, fea.Properties["pm25"])
+	a.Equal(int64(151), fea.Properties["fid"])
+	a.Len(fea.Properties["note"], 3000)
+
+	prop := "pm25"
+	p := NewKrigingInterpolator(Options{Input: fc, ValueProperty: &prop})
+	pos, _ := p.extractPosion()
+	a.Len(pos, 300)
+	a.Equal(149.5, pos[299][2])
+
+	_, _, err = ReadGeoPackage("testdata/points.gpkg", "missing")
+	a.NotNil(err)
+	_, _, err = ReadGeoPackage("test.json", "")
+	a.NotNil(err)
+}
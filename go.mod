@@ -10,6 +10,7 @@ require (
 	github.com/flywave/go-geoid v0.0.0-20210705014121-cd8f70cb88bb
 	github.com/flywave/go-geom v0.0.0-20250607125323-f685bf20f12c
 	github.com/flywave/go3d v0.0.0-20250314015505-bf0fda02e242
+	github.com/hhrutter/lzw v1.0.0
 	github.com/stretchr/testify v1.10.0
 	gonum.org/v1/gonum v0.8.2
 )
@@ -19,7 +20,6 @@ require (
 	github.com/flywave/go-geos v0.0.0-20250607125930-047054a9f657 // indirect
 	github.com/flywave/go-proj v0.0.0-20250607132305-d70d32f5ad2d // indirect
 	github.com/google/tiff v0.0.0-20161109161721-4b31f3041d9a // indirect
-	github.com/hhrutter/lzw v1.0.0 // indirect
 	github.com/kr/text v0.2.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/exp v0.0.0-20191030013958-a1ab85dbe136 // indirect
@@ -0,0 +1,99 @@
+package kriging
+
+import (
+	"errors"
+	"math"
+
+	vec3d "github.com/flywave/go3d/float64/vec3"
+)
+
+const (
+	default_idw_power      = 2
+	default_idw_neighbours = 12
+	default_idw_candidates = 4
+)
+
+type IDWOptions struct {
+	Power            float64
+	Radius           float64 // search radius in coordinate units, degrees once KrigingInterpolator has projected the input
+	MinNeighbours    int
+	MaxNeighbours    int
+	Sectors          int
+	SectorNeighbours int
+}
+
+type IDW struct {
+	pos     []vec3d.T
+	opts    IDWOptions
+	index   *pointIndex
+	barrier *breaklineIndex
+}
+
+func NewIDW(pos []vec3d.T, opts IDWOptions) (*IDW, error) {
+	if len(pos) == 0 {
+		return nil, errors.New("no input points")
+	}
+	if opts.Power <= 0 {
+		opts.Power = default_idw_power
+	}
+	if opts.MaxNeighbours <= 0 {
+		opts.MaxNeighbours = default_idw_neighbours
+	}
+	if opts.MinNeighbours <= 0 {
+		opts.MinNeighbours = 1
+	}
+	if opts.Sectors > 1 && opts.SectorNeighbours <= 0 {
+		opts.SectorNeighbours = int(math.Ceil(float64(opts.MaxNeighbours) / float64(opts.Sectors)))
+	}
+	return &IDW{pos: pos, opts: opts, index: newPointIndex(pos, suggestCellSize(pos, 4))}, nil
+}
+
+func (idw *IDW) neighbours(x, y float64) []neighbour {
+	if idw.opts.Sectors <= 1 {
+		return visibleNeighbours(idw.index, idw.barrier, x, y, idw.opts.MaxNeighbours, idw.opts.Radius)
+	}
+
+	k := idw.opts.Sectors * idw.opts.SectorNeighbours * default_idw_candidates
+	counts := make([]int, idw.opts.Sectors)
+	found := make([]neighbour, 0, idw.opts.Sectors*idw.opts.SectorNeighbours)
+	for _, nb := range visibleNeighbours(idw.index, idw.barrier, x, y, k, idw.opts.Radius) {
+		p := idw.pos[nb.index]
+		angle := math.Atan2(p[1]-y, p[0]-x) + math.Pi
+		s := int(angle / (2 * math.Pi) * float64(idw.opts.Sectors))
+		if s >= idw.opts.Sectors {
+			s = idw.opts.Sectors - 1
+		}
+		if counts[s] >= idw.opts.SectorNeighbours {
+			continue
+		}
+		counts[s]++
+		found = append(found, nb)
+	}
+	return found
+}
+
+func (idw *IDW) Predict(x, y float64) float64 {
+	nb := idw.neighbours(x, y)
+	if len(nb) == 0 || len(nb) < idw.opts.MinNeighbours {
+		return math.NaN()
+	}
+	if nb[0].dist == 0 {
+		sum, n := 0.0, 0
+		for _, b := range nb {
+			if b.dist != 0 {
+				break
+			}
+			sum += idw.pos[b.index][2]
+			n++
+		}
+		return sum / float64(n)
+	}
+
+	var sum, wsum float64
+	for _, b := range nb {
+		w := 1 / math.Pow(b.dist, idw.opts.Power)
+		sum += w * idw.pos[b.index][2]
+		wsum += w
+	}
+	return sum / wsum
+}
@@ -0,0 +1,63 @@
+package kriging
+
+import (
+	"math"
+	"testing"
+
+	vec2d "github.com/flywave/go3d/float64/vec2"
+	vec3d "github.com/flywave/go3d/float64/vec3"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIDW(t *testing.T) {
+	a := assert.New(t)
+
+	pos := krigingPoints()
+	idw, err := NewIDW(pos, IDWOptions{})
+	a.Nil(err)
+	for _, i := range []int{0, 20, 45} {
+		a.Equal(pos[i][2], idw.Predict(pos[i][0], pos[i][1]))
+	}
+	v := idw.Predict(35, 35)
+	a.True(v > 10 && v < 20)
+
+	idw, err = NewIDW(pos, IDWOptions{Radius: 5, MinNeighbours: 2})
+	a.Nil(err)
+	a.True(math.IsNaN(idw.Predict(35, 35)))
+	a.False(math.IsNaN(idw.Predict(35, 30)))
+
+	pair := []vec3d.T{{0, 0, 1}, {10, 0, 3}}
+	idw, err = NewIDW(pair, IDWOptions{Power: 1})
+	a.Nil(err)
+	a.InDelta(1.5, idw.Predict(2.5, 0), 1e-12)
+
+	line := []vec3d.T{{0, 1, 10}, {0, 2, 10}, {0, 3, 10}, {0, -5, 0}}
+	idw, err = NewIDW(line, IDWOptions{MaxNeighbours: 2})
+	a.Nil(err)
+	a.Equal(10.0, idw.Predict(0, 0))
+	idw, err = NewIDW(line, IDWOptions{MaxNeighbours: 2, Sectors: 2, SectorNeighbours: 1})
+	a.Nil(err)
+	a.InDelta(10*25.0/26, idw.Predict(0, 0), 1e-12)
+
+	_, err = NewIDW(nil, IDWOptions{})
+	a.NotNil(err)
+}
+
+func TestIDWTwoPointBoundary(t *testing.T) {
+	a := assert.New(t)
+
+	pair := []vec3d.T{{0, 0, 1}, {10, 0, 3}}
+	p := &KrigingInterpolator{inputPos: pair, method: IDWMethod, boundaryType: ConvexBoundary}
+	a.Nil(p.computeBoundary())
+	a.True(p.inside(vec2d.T{5, 0}))
+	a.True(p.inside(vec2d.T{5, 0.5}))
+	a.False(p.inside(vec2d.T{5, 2}))
+
+	predictor, err := p.computePredictor()
+	a.Nil(err)
+	a.Equal(2.0, predictor.Predict(5, 0))
+
+	p = &KrigingInterpolator{inputPos: pair, method: KrigingMethod, boundaryType: ConvexBoundary}
+	a.NotNil(p.computeBoundary())
+}
@@ -0,0 +1,197 @@
+package kriging
+
+import (
+	"math"
+	"sort"
+
+	vec2d "github.com/flywave/go3d/float64/vec2"
+	vec3d "github.com/flywave/go3d/float64/vec3"
+)
+
+type neighbour struct {
+	index int
+	dist  float64
+}
+
+type neighbourList []neighbour
+
+func (t neighbourList) Len() int {
+	return len(t)
+}
+
+func (t neighbourList) Less(i, j int) bool {
+	return t[i].dist < t[j].dist
+}
+
+func (t neighbourList) Swap(i, j int) {
+	t[i], t[j] = t[j], t[i]
+}
+
+type pointIndex struct {
+	pos      []vec3d.T
+	cellSize float64
+	cells    map[[2]int][]int
+	min      [2]int
+	max      [2]int
+}
+
+func newPointIndex(pos []vec3d.T, cellSize float64) *pointIndex {
+	if cellSize <= 0 || math.IsNaN(cellSize) || math.IsInf(cellSize, 0) {
+		cellSize = 1
+	}
+	idx := &pointIndex{
+		cellSize: cellSize,
+		cells:    make(map[[2]int][]int),
+		min:      [2]int{math.MaxInt32, math.MaxInt32},
+		max:      [2]int{math.MinInt32, math.MinInt32},
+	}
+	for i := range pos {
+		idx.Insert(pos[i])
+	}
+	return idx
+}
+
+func suggestCellSize(pos []vec3d.T, perCell int) float64 {
+	if len(pos) == 0 {
+		return 1
+	}
+	min, max, _ := minMaxVec3(pos)
+	area := (max[0] - min[0]) * (max[1] - min[1])
+	if area <= 0 {
+		return math.Max(math.Max(max[0]-min[0], max[1]-min[1]), 1e-9)
+	}
+	return math.Sqrt(area * float64(perCell) / float64(len(pos)))
+}
+
+func (idx *pointIndex) cell(x, y float64) [2]int {
+	return [2]int{int(math.Floor(x / idx.cellSize)), int(math.Floor(y / idx.cellSize))}
+}
+
+func (idx *pointIndex) Len() int {
+	return len(idx.pos)
+}
+
+func (idx *pointIndex) Insert(p vec3d.T) int {
+	i := len(idx.pos)
+	idx.pos = append(idx.pos, p)
+	c := idx.cell(p[0], p[1])
+	idx.cells[c] = append(idx.cells[c], i)
+	for k := 0; k < 2; k++ {
+		if c[k] < idx.min[k] {
+			idx.min[k] = c[k]
+		}
+		if c[k] > idx.max[k] {
+			idx.max[k] = c[k]
+		}
+	}
+	return i
+}
+
+func (idx *pointIndex) maxRing(c [2]int) int {
+	r := 0
+	for k := 0; k < 2; k++ {
+		if d := c[k] - idx.min[k]; d > r {
+			r = d
+		}
+		if d := idx.max[k] - c[k]; d > r {
+			r = d
+		}
+	}
+	return r
+}
+
+func (idx *pointIndex) visitRing(c [2]int, r int, fn func(i int)) {
+	for cx := c[0] - r; cx <= c[0]+r; cx++ {
+		for cy := c[1] - r; cy <= c[1]+r; cy++ {
+			if cx != c[0]-r && cx != c[0]+r && cy != c[1]-r && cy != c[1]+r {
+				continue
+			}
+			for _, i := range idx.cells[[2]int{cx, cy}] {
+				fn(i)
+			}
+		}
+	}
+}
+
+func (idx *pointIndex) Nearest(x, y float64, k int, radius float64) []neighbour {
+	if len(idx.pos) == 0 || k <= 0 {
+		return nil
+	}
+	c := idx.cell(x, y)
+	maxRing := idx.maxRing(c)
+
+	found := make(neighbourList, 0, k*2)
+	for r := 0; r <= maxRing; r++ {
+		idx.visitRing(c, r, func(i int) {
+			d := math.Hypot(idx.pos[i][0]-x, idx.pos[i][1]-y)
+			if radius <= 0 || d <= radius {
+				found = append(found, neighbour{i, d})
+			}
+		})
+		covered := float64(r) * idx.cellSize
+		if radius > 0 && covered >= radius {
+			break
+		}
+		if len(found) >= k {
+			sort.Sort(found)
+			if found[k-1].dist <= covered {
+				break
+			}
+		}
+	}
+	sort.Sort(found)
+	if len(found) > k {
+		found = found[:k]
+	}
+	return found
+}
+
+func (idx *pointIndex) Within(x, y float64, radius float64) []neighbour {
+	if len(idx.pos) == 0 || radius < 0 {
+		return nil
+	}
+	c := idx.cell(x, y)
+	rings := int(math.Ceil(radius/idx.cellSize)) + 1
+	if m := idx.maxRing(c); rings > m {
+		rings = m
+	}
+	found := make(neighbourList, 0)
+	for r := 0; r <= rings; r++ {
+		idx.visitRing(c, r, func(i int) {
+			d := math.Hypot(idx.pos[i][0]-x, idx.pos[i][1]-y)
+			if d <= radius {
+				found = append(found, neighbour{i, d})
+			}
+		})
+	}
+	sort.Sort(found)
+	return found
+}
+
+func (idx *pointIndex) Range(min, max vec2d.T) []int {
+	if len(idx.pos) == 0 {
+		return nil
+	}
+	lo, hi := idx.cell(min[0], min[1]), idx.cell(max[0], max[1])
+	for k := 0; k < 2; k++ {
+		if lo[k] < idx.min[k] {
+			lo[k] = idx.min[k]
+		}
+		if hi[k] > idx.max[k] {
+			hi[k] = idx.max[k]
+		}
+	}
+	found := []int{}
+	for cx := lo[0]; cx <= hi[0]; cx++ {
+		for cy := lo[1]; cy <= hi[1]; cy++ {
+			for _, i := range idx.cells[[2]int{cx, cy}] {
+				p := idx.pos[i]
+				if p[0] >= min[0] && p[0] <= max[0] && p[1] >= min[1] && p[1] <= max[1] {
+					found = append(found, i)
+				}
+			}
+		}
+	}
+	sort.Ints(found)
+	return found
+}
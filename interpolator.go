@@ -2,8 +2,14 @@ package kriging
 
 import (
 	"errors"
+	"fmt"
 	"image"
 	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/flywave/go-cog"
 	"github.com/flywave/go-geom/general"
@@ -19,6 +25,7 @@ import (
 const (
 	default_no_data     = float64(-9999)
 	default_no_data_str = "-9999"
+	default_box_padding = 0.1
 )
 
 const (
@@ -72,48 +79,186 @@ func (i *HyperbolicInterpolator) Interpolate(southWestHeight, southEastHeight, n
 }
 
 type KrigingInterpolator struct {
-	heightModel  geoid.VerticalDatum
-	heightOffset float64
-	pixelSize    *[2]float64
-	filterSize   [3]uint32
-	inputProj    geo.Proj
-	input        *geom.FeatureCollection
-	inputPos     []vec3d.T
-	model        ModelType
-	nodata       string
-	convexHull   *Convex
-	kriging      *Kriging
-	bounds       vec2d.Rect
-	output       string
-	background   *cog.Reader
-	interpolator string
+	heightModel       geoid.VerticalDatum
+	heightOffset      float64
+	pixelSize         *[2]float64
+	filterSize        [3]uint32
+	voxelFilter       VoxelFilterOptions
+	inputProj         geo.Proj
+	inputSrs          *string
+	input             *geom.FeatureCollection
+	source            PointSource
+	inputGeoJSON      *string
+	inputCSV          *string
+	csv               CSVOptions
+	inputLAS          *string
+	las               LASOptions
+	inputShapefile    *string
+	inputGeoPackage   *string
+	geoPackageLayer   string
+	inputPos          []vec3d.T
+	model             ModelType
+	nodata            string
+	convexHull        *Convex
+	boundaryType      BoundaryType
+	boundaryParam     float64
+	boundary          Boundary
+	hullBuffer        float64
+	maxDistance       float64
+	near              *distanceMask
+	kriging           *Kriging
+	bounds            vec2d.Rect
+	output            string
+	background        *cog.Reader
+	backgroundData    []float64
+	voidFill          *VoidFillOptions
+	interpolator      string
+	simulation        *SimulationOptions
+	timeProperty      *string
+	timestamps        []time.Time
+	stModel           SpaceTimeModelType
+	inputTimes        []float64
+	inputVar          []float64
+	inputWeights      []float64
+	inputIndex        []int
+	varianceProperty  *string
+	valueProperty     *string
+	report            Report
+	decluster         *DeclusterMethod
+	declusterSize     float64
+	outliers          []OutlierFilter
+	duplicates        *DuplicateOptions
+	weights           []float64
+	solver            *SolverOptions
+	tileSize          *[2]uint32
+	tileOverlap       int
+	method            MethodType
+	idw               IDWOptions
+	rbf               RBFOptions
+	mesh              *string
+	breaklineProperty *string
+	breaklineSpacing  float64
+	breaklines        []Breakline
+	clip              *geom.FeatureCollection
+	exclude           *geom.FeatureCollection
+	mask              *Mask
 }
 
 type Options struct {
-	HeightModel  geoid.VerticalDatum
-	HeightOffset float64
-	PixelSize    *[2]float64
-	InputSrs     *string
-	Input        *geom.FeatureCollection
-	Output       string
-	Background   *string
-	Model        *ModelType
-	Interpolator *string
-	FilterSize   *[3]uint32
+	HeightModel       geoid.VerticalDatum
+	HeightOffset      float64
+	PixelSize         *[2]float64
+	InputSrs          *string // overrides the SRS stored in the input file; LASOptions.Srs and CSVOptions.Srs take precedence
+	Input             *geom.FeatureCollection
+	Source            PointSource
+	InputGeoJSON      *string
+	InputCSV          *string
+	CSV               *CSVOptions
+	InputLAS          *string
+	LAS               *LASOptions
+	InputShapefile    *string
+	InputGeoPackage   *string
+	GeoPackageLayer   *string
+	Output            string
+	Background        *string
+	VoidFill          *VoidFillOptions
+	Model             *ModelType
+	Interpolator      *string
+	FilterSize        *[3]uint32
+	Filter            *VoxelFilterOptions
+	Simulation        *SimulationOptions
+	TimeProperty      *string
+	Timestamps        []time.Time
+	STModel           *SpaceTimeModelType
+	VarianceProperty  *string
+	ValueProperty     *string
+	Decluster         *DeclusterMethod
+	DeclusterSize     *float64 // cell size in degrees
+	Outliers          []OutlierFilter
+	Duplicates        *DuplicateOptions
+	Solver            *SolverOptions
+	TileSize          *[2]uint32
+	TileOverlap       *uint32
+	Method            *MethodType
+	IDW               *IDWOptions
+	RBF               *RBFOptions
+	Mesh              *string
+	BreaklineProperty *string
+	BreaklineSpacing  *float64
+	Clip              *geom.FeatureCollection
+	Exclude           *geom.FeatureCollection
+	Boundary          *BoundaryType
+	BoundaryParam     *float64 // alpha radius in degrees, or k for KNNBoundary
+	HullBuffer        *float64
+	MaxDistance       *float64
 }
 
 func NewKrigingInterpolator(opts Options) *KrigingInterpolator {
 	inter := &KrigingInterpolator{
-		input:        opts.Input,
-		heightModel:  opts.HeightModel,
-		heightOffset: opts.HeightOffset,
-		pixelSize:    opts.PixelSize,
-		output:       opts.Output,
-		nodata:       default_no_data_str,
+		input:             opts.Input,
+		voidFill:          opts.VoidFill,
+		source:            opts.Source,
+		inputGeoJSON:      opts.InputGeoJSON,
+		inputCSV:          opts.InputCSV,
+		inputLAS:          opts.InputLAS,
+		inputShapefile:    opts.InputShapefile,
+		inputGeoPackage:   opts.InputGeoPackage,
+		heightModel:       opts.HeightModel,
+		heightOffset:      opts.HeightOffset,
+		pixelSize:         opts.PixelSize,
+		output:            opts.Output,
+		nodata:            default_no_data_str,
+		simulation:        opts.Simulation,
+		timeProperty:      opts.TimeProperty,
+		timestamps:        opts.Timestamps,
+		varianceProperty:  opts.VarianceProperty,
+		valueProperty:     opts.ValueProperty,
+		decluster:         opts.Decluster,
+		outliers:          opts.Outliers,
+		duplicates:        opts.Duplicates,
+		solver:            opts.Solver,
+		tileSize:          opts.TileSize,
+		mesh:              opts.Mesh,
+		breaklineProperty: opts.BreaklineProperty,
+		clip:              opts.Clip,
+		exclude:           opts.Exclude,
+	}
+
+	if opts.TileOverlap != nil {
+		inter.tileOverlap = int(*opts.TileOverlap)
+	} else {
+		inter.tileOverlap = default_tile_overlap
+	}
+
+	if opts.BreaklineSpacing != nil {
+		inter.breaklineSpacing = *opts.BreaklineSpacing
+	}
+
+	if opts.Boundary != nil {
+		inter.boundaryType = *opts.Boundary
+	} else {
+		inter.boundaryType = ConvexBoundary
+	}
+
+	if opts.BoundaryParam != nil {
+		inter.boundaryParam = *opts.BoundaryParam
+	}
+
+	if opts.HullBuffer != nil {
+		inter.hullBuffer = *opts.HullBuffer
+	}
+
+	if opts.MaxDistance != nil {
+		inter.maxDistance = *opts.MaxDistance
+	}
+
+	if opts.DeclusterSize != nil {
+		inter.declusterSize = *opts.DeclusterSize
 	}
 
 	if opts.InputSrs != nil {
-		inter.inputProj = geo.NewProj(opts.InputSrs)
+		inter.inputSrs = opts.InputSrs
+		inter.inputProj = geo.NewProj(*opts.InputSrs)
 	}
 
 	if opts.Model != nil {
@@ -122,6 +267,42 @@ func NewKrigingInterpolator(opts Options) *KrigingInterpolator {
 		inter.model = Gaussian
 	}
 
+	if opts.Method != nil {
+		inter.method = *opts.Method
+	} else {
+		inter.method = KrigingMethod
+	}
+
+	if opts.IDW != nil {
+		inter.idw = *opts.IDW
+	}
+
+	if opts.CSV != nil {
+		inter.csv = *opts.CSV
+	}
+
+	if opts.Filter != nil {
+		inter.voxelFilter = *opts.Filter
+	}
+
+	if opts.GeoPackageLayer != nil {
+		inter.geoPackageLayer = *opts.GeoPackageLayer
+	}
+
+	if opts.LAS != nil {
+		inter.las = *opts.LAS
+	}
+
+	if opts.RBF != nil {
+		inter.rbf = *opts.RBF
+	}
+
+	if opts.STModel != nil {
+		inter.stModel = *opts.STModel
+	} else {
+		inter.stModel = Separable
+	}
+
 	if opts.Interpolator == nil {
 		inter.interpolator = BILINEAR
 	} else {
@@ -141,150 +322,124 @@ func NewKrigingInterpolator(opts Options) *KrigingInterpolator {
 	return inter
 }
 
-func (p *KrigingInterpolator) extractPosion() []vec3d.T {
-	ret := make([]vec3d.T, 0, 1000)
+func (p *KrigingInterpolator) loadInput() error {
+	var fc *geom.FeatureCollection
+	var srs string
+	var err error
+	switch {
+	case p.inputShapefile != nil:
+		fc, srs, err = ReadShapefile(*p.inputShapefile)
+	case p.inputGeoPackage != nil:
+		fc, srs, err = ReadGeoPackage(*p.inputGeoPackage, p.geoPackageLayer)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	p.input = fc
+	if p.inputProj == nil && srs != "" {
+		if p.inputProj = wktProj(srs); p.inputProj == nil {
+			return errors.New("cannot resolve the input coordinate system, set InputSrs")
+		}
+	}
+	return nil
+}
 
-	for _, feas := range p.input.Features {
-		g_ := feas.Geometry
-		switch g := g_.(type) {
-		case *general.Point:
-			if p.inputProj != nil && !p.inputProj.Eq(epsg4326) {
-				pos2 := []vec2d.T{{g.X(), g.Y()}}
-				pos2 = p.inputProj.TransformTo(epsg4326, pos2)
-				ret = append(ret, vec3d.T{pos2[0][0], pos2[0][1], g.Data()[2]})
-			} else {
-				ret = append(ret, vec3d.T{g.X(), g.Y(), g.Data()[2]})
-			}
-		case *general.Point3:
-			if p.inputProj != nil && !p.inputProj.Eq(epsg4326) {
-				pos2 := []vec2d.T{{g.X(), g.Y()}}
-				pos2 = p.inputProj.TransformTo(epsg4326, pos2)
-				ret = append(ret, vec3d.T{pos2[0][0], pos2[0][1], g.Data()[2]})
-			} else {
-				ret = append(ret, vec3d.T{g.X(), g.Y(), g.Data()[2]})
-			}
-		case *general.MultiPoint:
-			for _, pos := range g.Points() {
-				if p.inputProj != nil && !p.inputProj.Eq(epsg4326) {
-					pos2 := []vec2d.T{{pos.X(), pos.Y()}}
-					pos2 = p.inputProj.TransformTo(epsg4326, pos2)
-					ret = append(ret, vec3d.T{pos2[0][0], pos2[0][1], pos.Data()[2]})
-				} else {
-					ret = append(ret, vec3d.T{pos.X(), pos.Y(), pos.Data()[2]})
-				}
-			}
-		case *general.MultiPoint3:
-			for _, pos := range g.Points() {
-				if p.inputProj != nil && !p.inputProj.Eq(epsg4326) {
-					pos2 := []vec2d.T{{pos.X(), pos.Y()}}
-					pos2 = p.inputProj.TransformTo(epsg4326, pos2)
-					ret = append(ret, vec3d.T{pos2[0][0], pos2[0][1], pos.Data()[2]})
-				} else {
-					ret = append(ret, vec3d.T{pos.X(), pos.Y(), pos.Data()[2]})
-				}
-			}
-		case *general.LineString:
-			for _, pos := range g.Subpoints() {
-				if p.inputProj != nil && !p.inputProj.Eq(epsg4326) {
-					pos2 := []vec2d.T{{pos.X(), pos.Y()}}
-					pos2 = p.inputProj.TransformTo(epsg4326, pos2)
-					ret = append(ret, vec3d.T{pos2[0][0], pos2[0][1], pos.Data()[2]})
-				} else {
-					ret = append(ret, vec3d.T{pos.X(), pos.Y(), pos.Data()[2]})
-				}
-			}
-		case *general.LineString3:
-			for _, pos := range g.Subpoints() {
-				if p.inputProj != nil && !p.inputProj.Eq(epsg4326) {
-					pos2 := []vec2d.T{{pos.X(), pos.Y()}}
-					pos2 = p.inputProj.TransformTo(epsg4326, pos2)
-					ret = append(ret, vec3d.T{pos2[0][0], pos2[0][1], pos.Data()[2]})
-				} else {
-					ret = append(ret, vec3d.T{pos.X(), pos.Y(), pos.Data()[2]})
-				}
-			}
-		case *general.MultiLine:
-			for _, li := range g.Lines() {
-				for _, pos := range li.Subpoints() {
-					if p.inputProj != nil && !p.inputProj.Eq(epsg4326) {
-						pos2 := []vec2d.T{{pos.X(), pos.Y()}}
-						pos2 = p.inputProj.TransformTo(epsg4326, pos2)
-						ret = append(ret, vec3d.T{pos2[0][0], pos2[0][1], pos.Data()[2]})
-					} else {
-						ret = append(ret, vec3d.T{pos.X(), pos.Y(), pos.Data()[2]})
-					}
-				}
-			}
-		case *general.MultiLine3:
-			for _, li := range g.Lines() {
-				for _, pos := range li.Subpoints() {
-					if p.inputProj != nil && !p.inputProj.Eq(epsg4326) {
-						pos2 := []vec2d.T{{pos.X(), pos.Y()}}
-						pos2 = p.inputProj.TransformTo(epsg4326, pos2)
-						ret = append(ret, vec3d.T{pos2[0][0], pos2[0][1], pos.Data()[2]})
-					} else {
-						ret = append(ret, vec3d.T{pos.X(), pos.Y(), pos.Data()[2]})
-					}
-				}
-			}
-		case *general.Polygon:
-			for _, sli := range g.Sublines() {
-				for _, pos := range sli.Subpoints() {
-					if p.inputProj != nil && !p.inputProj.Eq(epsg4326) {
-						pos2 := []vec2d.T{{pos.X(), pos.Y()}}
-						pos2 = p.inputProj.TransformTo(epsg4326, pos2)
-						ret = append(ret, vec3d.T{pos2[0][0], pos2[0][1], pos.Data()[2]})
-					} else {
-						ret = append(ret, vec3d.T{pos.X(), pos.Y(), pos.Data()[2]})
-					}
-				}
-			}
-		case *general.Polygon3:
-			for _, sli := range g.Sublines() {
-				for _, pos := range sli.Subpoints() {
-					if p.inputProj != nil && !p.inputProj.Eq(epsg4326) {
-						pos2 := []vec2d.T{{pos.X(), pos.Y()}}
-						pos2 = p.inputProj.TransformTo(epsg4326, pos2)
-						ret = append(ret, vec3d.T{pos2[0][0], pos2[0][1], pos.Data()[2]})
-					} else {
-						ret = append(ret, vec3d.T{pos.X(), pos.Y(), pos.Data()[2]})
-					}
-				}
-			}
-		case *general.MultiPolygon:
-			for _, poly := range g.Polygons() {
-				for _, sli := range poly.Sublines() {
-					for _, pos := range sli.Subpoints() {
-						if p.inputProj != nil && !p.inputProj.Eq(epsg4326) {
-							pos2 := []vec2d.T{{pos.X(), pos.Y()}}
-							pos2 = p.inputProj.TransformTo(epsg4326, pos2)
-							ret = append(ret, vec3d.T{pos2[0][0], pos2[0][1], pos.Data()[2]})
-						} else {
-							ret = append(ret, vec3d.T{pos.X(), pos.Y(), pos.Data()[2]})
-						}
-					}
-				}
-			}
-		case *general.MultiPolygon3:
-			for _, poly := range g.Polygons() {
-				for _, sli := range poly.Sublines() {
-					for _, pos := range sli.Subpoints() {
-						if p.inputProj != nil && !p.inputProj.Eq(epsg4326) {
-							pos2 := []vec2d.T{{pos.X(), pos.Y()}}
-							pos2 = p.inputProj.TransformTo(epsg4326, pos2)
-							ret = append(ret, vec3d.T{pos2[0][0], pos2[0][1], pos.Data()[2]})
-						} else {
-							ret = append(ret, vec3d.T{pos.X(), pos.Y(), pos.Data()[2]})
-						}
-					}
-				}
-			}
+func (p *KrigingInterpolator) featureSource() *FeatureSource {
+	src := NewFeatureSource(p.input, FeatureSourceOptions{ValueProperty: p.valueProperty, VarianceProperty: p.varianceProperty, TimeProperty: p.timeProperty})
+	src.proj = p.inputProj
+	src.breakline = p.extractBreaklines
+	return src
+}
+
+func (p *KrigingInterpolator) extractPosion() ([]vec3d.T, []float64) {
+	src := p.featureSource()
+	ret, variance, _ := p.readSource(src)
+	p.breaklines = src.lines
+	return ret, variance
+}
+
+func (p *KrigingInterpolator) extractBreaklines(feas *geom.Feature) []Breakline {
+	if p.breaklineProperty == nil {
+		return nil
+	}
+	bt, ok := breaklineType(feas.Properties[*p.breaklineProperty])
+	if !ok {
+		return nil
+	}
+
+	var geoms []geom.Geometry
+	switch g := feas.Geometry.(type) {
+	case *general.LineString, *general.LineString3:
+		geoms = append(geoms, g)
+	case *general.MultiLine:
+		for _, li := range g.Lines() {
+			geoms = append(geoms, li)
 		}
+	case *general.MultiLine3:
+		for _, li := range g.Lines() {
+			geoms = append(geoms, li)
+		}
+	default:
+		return nil
+	}
+
+	lines := make([]Breakline, 0, len(geoms))
+	for _, g := range geoms {
+		lines = append(lines, Breakline{Type: bt, Vertices: densifyLine(p.geometryPosion(g, nil), p.breaklineSpacing)})
+	}
+	return lines
+}
+
+func propertyFloat(v interface{}) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case float32:
+		return float64(t), true
+	case int:
+		return float64(t), true
+	case int64:
+		return float64(t), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func (p *KrigingInterpolator) geometryPosion(g geom.Geometry, ret []vec3d.T) []vec3d.T {
+	return projectGeometry(p.inputProj, g, ret)
+}
+
+func pointZ(data []float64) float64 {
+	if len(data) < 3 {
+		return 0
 	}
-	return ret
+	return data[2]
 }
 
-func (p *KrigingInterpolator) filter(inputPos []vec3d.T) ([]vec3d.T, error) {
+func (p *KrigingInterpolator) readInput() ([]vec3d.T, []float64, error) {
+	switch {
+	case p.source != nil:
+		return p.readSource(p.source)
+	case p.inputGeoJSON != nil:
+		return p.readGeoJSON()
+	case p.inputCSV != nil:
+		return p.readCSV()
+	case p.inputLAS != nil:
+		return p.readLAS()
+	}
+	pos, variance := p.extractPosion()
+	return pos, variance, nil
+}
+
+func (p *KrigingInterpolator) filter(inputPos []vec3d.T, inputVar []float64) ([]vec3d.T, []float64, error) {
+	if p.voxelFilter.Disabled {
+		return inputPos, inputVar, nil
+	}
+
 	min, max, _ := minMaxVec3(inputPos)
 
 	vg := newVoxelGrid(vec3d.T{
@@ -292,29 +447,95 @@ func (p *KrigingInterpolator) filter(inputPos []vec3d.T) ([]vec3d.T, error) {
 		(max[1] - min[1]) / float64(p.filterSize[1]),
 		(max[2] - min[2]) / float64(p.filterSize[2]),
 	})
+	if p.voxelFilter.LeafSize > 0 {
+		dx, dy := metresToDegrees(p.voxelFilter.LeafSize, (min[1]+max[1])/2)
+		vg.LeafSize = vec3d.T{dx, dy, p.voxelFilter.LeafHeight}
+	}
+	if p.voxelFilter.Reducer != "" {
+		vg.Reducer = p.voxelFilter.Reducer
+	}
+
+	if p.inputWeights != nil {
+		weights, err := vg.FilterWeights(inputPos, p.inputWeights)
+		if err != nil {
+			return nil, nil, err
+		}
+		p.inputWeights = weights
+	}
+
+	if inputVar != nil {
+		return vg.FilterVariance(inputPos, inputVar)
+	}
 
 	res, err := vg.Filter(inputPos)
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return res, nil
+	return res, nil, nil
 }
 
 func (p *KrigingInterpolator) Process() (vec2d.Rect, geo.Proj, error) {
-	pos := p.extractPosion()
+	if p.voidFill != nil {
+		return p.processVoidFill()
+	}
+
+	if p.tileSize != nil && p.simulation != nil {
+		return vec2d.Rect{}, nil, errors.New("tiled output is not supported for simulation")
+	}
+
+	if err := p.loadInput(); err != nil {
+		return vec2d.Rect{}, nil, err
+	}
 
-	pos, err := p.filter(pos)
+	if p.timeProperty != nil {
+		return p.processSpaceTime()
+	}
+
+	pos, variance, err := p.readInput()
+	if err != nil {
+		return vec2d.Rect{}, nil, err
+	}
+
+	if p.duplicates != nil {
+		pos, variance, err = p.mergeDuplicates(pos, variance)
+		if err != nil {
+			return vec2d.Rect{}, nil, err
+		}
+	}
+
+	pos, variance, err = p.removeOutliers(pos, variance)
+	if err != nil {
+		return vec2d.Rect{}, nil, err
+	}
+
+	pos, variance, err = p.filter(pos, variance)
 
 	if err != nil {
 		return vec2d.Rect{}, nil, err
 	}
 
 	p.inputPos = pos
+	p.inputVar = variance
 
-	p.convertHeight()
-	p.computeConvexHull()
-	p.computeKriging()
+	if err := p.convertHeight(); err != nil {
+		return vec2d.Rect{}, nil, err
+	}
+	if err := p.computeBoundary(); err != nil {
+		return vec2d.Rect{}, nil, err
+	}
+	p.computeMask()
+	p.computeWeights()
+
+	if p.mesh != nil {
+		if err := p.writeMesh(*p.mesh); err != nil {
+			return vec2d.Rect{}, nil, err
+		}
+	}
+
+	if p.tileSize != nil {
+		return p.processTiled()
+	}
 
 	grid := p.cacleGrid()
 
@@ -322,32 +543,255 @@ func (p *KrigingInterpolator) Process() (vec2d.Rect, geo.Proj, error) {
 		return vec2d.Rect{}, nil, errors.New("gen grid error")
 	}
 
-	p.resample(grid)
+	if p.simulation != nil {
+		return grid.GetRect(), grid.srs, p.simulate(grid)
+	}
 
+	predictor, err := p.computePredictor()
+	if err != nil {
+		return vec2d.Rect{}, nil, err
+	}
+	p.resample(grid, predictor.Predict)
+
+	return grid.GetRect(), grid.srs, p.writeGrid(grid, p.output)
+}
+
+func (p *KrigingInterpolator) processSpaceTime() (vec2d.Rect, geo.Proj, error) {
+	if len(p.timestamps) == 0 {
+		return vec2d.Rect{}, nil, errors.New("no timestamps requested")
+	}
+	pos, _, err := p.readInput()
+	if err != nil {
+		return vec2d.Rect{}, nil, err
+	}
+	if len(pos) == 0 {
+		return vec2d.Rect{}, nil, errors.New("no timestamped points")
+	}
+	if p.inputTimes == nil {
+		return vec2d.Rect{}, nil, errors.New("input has no timestamps")
+	}
+
+	p.inputPos = pos
+
+	if err := p.convertHeight(); err != nil {
+		return vec2d.Rect{}, nil, err
+	}
+	if err := p.computeBoundary(); err != nil {
+		return vec2d.Rect{}, nil, err
+	}
+	p.computeMask()
+
+	grid := p.cacleGrid()
+
+	if grid == nil {
+		return vec2d.Rect{}, nil, errors.New("gen grid error")
+	}
+
+	st, err := NewSpaceTime(p.inputPos, p.inputTimes).Train(p.stModel, p.model, p.model, 0)
+	if err != nil {
+		return vec2d.Rect{}, nil, err
+	}
+
+	for _, ts := range p.timestamps {
+		t := timeValue(ts)
+		p.resample(grid, func(x, y float64) float64 {
+			return st.Predict(x, y, t)
+		})
+		if err := p.writeGrid(grid, p.outputPath("_"+ts.UTC().Format("20060102T150405"))); err != nil {
+			return vec2d.Rect{}, nil, err
+		}
+	}
+
+	return grid.GetRect(), grid.srs, nil
+}
+
+func (p *KrigingInterpolator) writeGrid(grid *Grid, output string) error {
 	tiledata, si, bbox, srs := grid.GetDate()
 
 	rect := image.Rect(0, 0, int(si[0]), int(si[1]))
 
 	src := cog.NewSource(tiledata, &rect, cog.CTLZW)
 
-	return bbox, srs, cog.WriteTile(p.output, src, bbox, srs, si, &p.nodata)
+	return cog.WriteTile(output, src, bbox, srs, si, &p.nodata)
+}
+
+func (p *KrigingInterpolator) writeMesh(output string) error {
+	tin, err := NewDelaunay(p.inputPos)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(output), ".obj") {
+		return tin.WriteOBJ(f)
+	}
+	return tin.WriteGeoJSON(f)
+}
+
+func (p *KrigingInterpolator) outputPath(suffix string) string {
+	ext := filepath.Ext(p.output)
+	return strings.TrimSuffix(p.output, ext) + suffix + ext
 }
 
-func (p *KrigingInterpolator) computeConvexHull() []vec2d.T {
+func (p *KrigingInterpolator) computeConvexHull() ([]vec2d.T, error) {
 	p.convexHull = NewConvex(p.inputPos)
-	return p.convexHull.Hull()
+	return p.convexHull.Build()
+}
+
+func paddedBox(vertices []vec3d.T) []vec3d.T {
+	r := vec2d.Rect{Min: vec2d.MaxVal, Max: vec2d.MinVal}
+	for i := range vertices {
+		r.Extend(&vec2d.T{vertices[i][0], vertices[i][1]})
+	}
+	pad := default_box_padding * math.Hypot(r.Max[0]-r.Min[0], r.Max[1]-r.Min[1])
+	if !(pad > 0) {
+		return nil
+	}
+	return []vec3d.T{
+		{r.Min[0] - pad, r.Min[1] - pad, 0},
+		{r.Max[0] + pad, r.Min[1] - pad, 0},
+		{r.Max[0] + pad, r.Max[1] + pad, 0},
+		{r.Min[0] - pad, r.Max[1] + pad, 0},
+	}
+}
+
+func (p *KrigingInterpolator) computeBoundary() error {
+	if _, err := p.computeConvexHull(); err != nil {
+		// IDW and RBF do not need a triangulation, so fewer than three or collinear points fall back to a padded bounding box
+		if p.method != IDWMethod && p.method != RBFMethod {
+			return err
+		}
+		box := paddedBox(p.inputPos)
+		if box == nil {
+			return err
+		}
+		p.convexHull = NewConvex(box)
+		if _, err := p.convexHull.Build(); err != nil {
+			return err
+		}
+	}
+	p.boundary = p.convexHull
+	switch p.boundaryType {
+	case AlphaBoundary:
+		c, err := NewAlphaShape(p.inputPos, p.boundaryParam)
+		if err != nil {
+			return err
+		}
+		p.boundary = c
+	case KNNBoundary:
+		c, err := NewKNNHull(p.inputPos, int(p.boundaryParam))
+		if err != nil {
+			return err
+		}
+		p.boundary = c
+	}
+	if p.hullBuffer != 0 {
+		p.boundary = newBufferedBoundary(p.boundary, p.hullBuffer)
+	}
+	return nil
+}
+
+func (p *KrigingInterpolator) computePredictor() (Predictor, error) {
+	barrier := newBreaklineIndex(p.breaklines)
+	if barrier != nil && p.method != KrigingMethod && p.method != IDWMethod {
+		return nil, fmt.Errorf("hard breaklines are not supported by the %s method", p.method)
+	}
+
+	switch p.method {
+	case IDWMethod:
+		idw, err := NewIDW(p.inputPos, p.idw)
+		if err != nil {
+			return nil, err
+		}
+		idw.barrier = barrier
+		return idw, nil
+	case RBFMethod:
+		return NewRBF(p.inputPos, p.rbf)
+	case TINMethod:
+		return NewDelaunay(p.inputPos)
+	case NaturalMethod:
+		return NewNaturalNeighbour(p.inputPos)
+	default:
+		if barrier != nil {
+			// breakline kriging solves a small local system per node, so p.solver does not apply
+			kri := NewWithVariance(p.inputPos, p.inputVar)
+			kri.SetWeights(p.weights)
+			if err := kri.fitVariogram(p.model, 100); err != nil {
+				return nil, err
+			}
+			return kri.localPredictor(default_breakline_neighbours, barrier), nil
+		}
+		if err := p.computeKriging(); err != nil {
+			return nil, err
+		}
+		return p.kriging, nil
+	}
+}
+
+func (p *KrigingInterpolator) computeMask() {
+	p.mask = nil
+	if p.clip != nil || p.exclude != nil {
+		p.mask = NewMask(p.maskPolygons(p.clip), p.maskPolygons(p.exclude))
+	}
+	p.near = nil
+	if p.maxDistance > 0 {
+		p.near = newDistanceMask(p.inputPos, p.maxDistance)
+	}
+}
+
+func (p *KrigingInterpolator) inside(pt vec2d.T) bool {
+	return p.boundary.InHull(vec3d.Zero, zRotator(), pt) && p.mask.Contains(pt) && p.near.Contains(pt)
 }
 
 func (p *KrigingInterpolator) computeKriging() error {
-	p.kriging = New(p.inputPos)
+	p.kriging = NewWithVariance(p.inputPos, p.inputVar)
+	p.kriging.SetWeights(p.weights)
+	if p.solver != nil {
+		p.kriging.SetSolver(*p.solver)
+	}
 	_, err := p.kriging.Train(p.model, 0, 100)
 	return err
 }
 
+func (p *KrigingInterpolator) computeWeights() {
+	if p.decluster == nil {
+		p.weights = p.inputWeights
+		return
+	}
+	p.weights = Decluster(p.inputPos, *p.decluster, p.declusterSize)
+	if p.inputWeights != nil {
+		for i := range p.weights {
+			p.weights[i] *= p.inputWeights[i]
+		}
+		p.weights = normaliseWeights(p.weights)
+	}
+}
+
+func (p *KrigingInterpolator) Report() Report {
+	return p.report
+}
+
+func (p *KrigingInterpolator) Statistics() Statistics {
+	return NewStatistics(p.inputPos, p.weights)
+}
+
 func (p *KrigingInterpolator) cacleGrid() *Grid {
-	if p.convexHull == nil {
+	width, height, ok := p.cacleExtent()
+	if !ok {
 		return nil
 	}
+	return CaclulateGrid(width, height, geo.NewGeoReference(p.bounds, epsg4326))
+}
+
+func (p *KrigingInterpolator) cacleExtent() (int, int, bool) {
+	if p.boundary == nil {
+		return 0, 0, false
+	}
 	var width, height int
 	if p.background != nil {
 		ps := p.background.GetPixelSize(0)
@@ -357,7 +801,7 @@ func (p *KrigingInterpolator) cacleGrid() *Grid {
 		width, height = int(si[0]), int(si[1])
 		epsgcode, err := p.background.GetEPSGCode(0)
 		if err != nil {
-			return nil
+			return 0, 0, false
 		}
 		if epsgcode != 4326 {
 			proj := geo.NewProj(epsgcode)
@@ -376,48 +820,106 @@ func (p *KrigingInterpolator) cacleGrid() *Grid {
 			ps := [2]float64{grid.Resolutions[13], grid.Resolutions[16]}
 			p.pixelSize = &ps
 		}
-		p.bounds = p.convexHull.Rect()
+		p.bounds = p.boundary.Rect()
 
 		width, height = int((p.bounds.Max[0]-p.bounds.Min[0])/p.pixelSize[0]), int((p.bounds.Max[1]-p.bounds.Min[1])/p.pixelSize[1])
 	}
-	grid := CaclulateGrid(width, height, geo.NewGeoReference(p.bounds, epsg4326))
-	return grid
+	return width, height, true
+}
+
+func (p *KrigingInterpolator) resample(grid *Grid, predict func(x, y float64) float64) error {
+	outside := p.outsideValue()
+
+	for i := range grid.Coordinates {
+		x, y := grid.Coordinates[i][0], grid.Coordinates[i][1]
+		grid.Coordinates[i][2] = math.NaN()
+		if p.inside(vec2d.T{x, y}) {
+			grid.Coordinates[i][2] = predict(x, y)
+		}
+		if math.IsNaN(grid.Coordinates[i][2]) {
+			grid.Coordinates[i][2] = outside(x, y)
+		}
+	}
+	return nil
 }
 
-func (p *KrigingInterpolator) resample(grid *Grid) error {
+func (p *KrigingInterpolator) outsideValue() func(x, y float64) float64 {
 	if p.background == nil {
-		for i := range grid.Coordinates {
-			if p.convexHull.InHull(vec3d.Zero, zRotator(), vec2d.T{grid.Coordinates[i][0], grid.Coordinates[i][1]}) {
-				grid.Coordinates[i][2] = p.kriging.Predict(grid.Coordinates[i][0], grid.Coordinates[i][1])
-			} else {
-				grid.Coordinates[i][2] = default_no_data
-			}
+		return func(x, y float64) float64 {
+			return default_no_data
 		}
+	}
+
+	var interpolator Interpolator
+
+	if p.interpolator == HYPERBOLIC {
+		interpolator = &HyperbolicInterpolator{}
 	} else {
-		var interpolator Interpolator
+		interpolator = &BilinearInterpolator{}
+	}
+
+	georef := geo.NewGeoReference(p.bounds, epsg4326)
 
-		if p.interpolator == HYPERBOLIC {
-			interpolator = &HyperbolicInterpolator{}
+	return func(x, y float64) float64 {
+		return p.GetElevation(x, y, georef, interpolator)
+	}
+}
+
+func (p *KrigingInterpolator) simulate(grid *Grid) error {
+	sim, err := NewSimulation(p.inputPos, p.model, *p.simulation)
+	if err != nil {
+		return err
+	}
+
+	outside := p.outsideValue()
+
+	var nodes []vec2d.T
+	var indices []int
+	for i := range grid.Coordinates {
+		pt := vec2d.T{grid.Coordinates[i][0], grid.Coordinates[i][1]}
+		if p.inside(pt) {
+			nodes = append(nodes, pt)
+			indices = append(indices, i)
 		} else {
-			interpolator = &BilinearInterpolator{}
+			grid.Coordinates[i][2] = outside(pt[0], pt[1])
 		}
+	}
 
-		georef := geo.NewGeoReference(p.bounds, epsg4326)
+	write := func(values []float64, suffix string) error {
+		for k, i := range indices {
+			grid.Coordinates[i][2] = values[k]
+		}
+		return p.writeGrid(grid, p.outputPath(suffix))
+	}
 
-		for i := range grid.Coordinates {
-			if p.convexHull.InHull(vec3d.Zero, zRotator(), vec2d.T{grid.Coordinates[i][0], grid.Coordinates[i][1]}) {
-				grid.Coordinates[i][2] = p.kriging.Predict(grid.Coordinates[i][0], grid.Coordinates[i][1])
-			} else {
-				grid.Coordinates[i][2] = p.GetElevation(grid.Coordinates[i][0], grid.Coordinates[i][1], georef, interpolator)
-			}
+	if !p.simulation.Summary {
+		return sim.Run(nodes, func(r int, values []float64) error {
+			return write(values, fmt.Sprintf("_%03d", r+1))
+		})
+	}
+
+	etype, quantiles, err := sim.Summarise(nodes)
+	if err != nil {
+		return err
+	}
+	if err := write(etype, "_etype"); err != nil {
+		return err
+	}
+	for q := range quantiles {
+		suffix := "_q" + strconv.FormatFloat(p.simulation.Quantiles[q]*100, 'f', -1, 64)
+		if err := write(quantiles[q], suffix); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
-func (p *KrigingInterpolator) convertHeight() {
+func (p *KrigingInterpolator) convertHeight() error {
 	if (p.heightModel == geoid.HAE && p.heightOffset == 0) || p.heightModel == geoid.UNKNOWN {
-		return
+		return nil
+	}
+	if p.valueProperty != nil {
+		return errors.New("height conversion cannot be applied to values read from a property")
 	}
 	for i := range p.inputPos {
 		if p.heightModel == geoid.HAE {
@@ -427,6 +929,7 @@ func (p *KrigingInterpolator) convertHeight() {
 			p.inputPos[i][2] = gid.ConvertHeight(p.inputPos[i][0], p.inputPos[i][1], p.inputPos[i][2], geoid.GEOIDTOELLIPSOID)
 		}
 	}
+	return nil
 }
 
 func getAverageExceptForNoDataValue(noData, valueIfAllBad float64, values ...float64) float64 {
@@ -455,8 +958,15 @@ const (
 	NO_DATA_OUT = 0
 )
 
+func (s *KrigingInterpolator) backgroundValues() []float64 {
+	if s.backgroundData == nil {
+		s.backgroundData = rasterValues(s.background.Data[0])
+	}
+	return s.backgroundData
+}
+
 func (s *KrigingInterpolator) getBackgroundElevation(x, y int) float64 {
-	data := s.background.Data[0].([]float64)
+	data := s.backgroundValues()
 	si := s.background.GetSize(0)
 	if x >= int(si[0]) {
 		x = int(si[0] - 1)
@@ -9,7 +9,9 @@ import (
 )
 
 type Kriging struct {
-	pos []vec3d.T
+	pos      []vec3d.T
+	variance []float64
+	weights  []float64
 
 	nugget float64
 	rangex float64
@@ -20,13 +22,27 @@ type Kriging struct {
 	K []float64
 	M []float64
 
-	model KrigingModel
+	model  KrigingModel
+	solver SolverOptions
+	approx *approxSystem
 }
 
 func New(pos []vec3d.T) *Kriging {
 	return &Kriging{pos: pos}
 }
 
+func NewWithVariance(pos []vec3d.T, variance []float64) *Kriging {
+	return &Kriging{pos: pos, variance: variance}
+}
+
+func (kri *Kriging) SetWeights(weights []float64) {
+	kri.weights = weights
+}
+
+func (kri *Kriging) Statistics() Statistics {
+	return NewStatistics(kri.pos, kri.weights)
+}
+
 type KrigingModel func(float64, float64, float64, float64, float64) float64
 
 func krigingKrigingGaussian(h, nugget, range_, sill, A float64) float64 {
@@ -48,36 +64,33 @@ func krigingKrigingSpherical(h, nugget, range_, sill, A float64) float64 {
 	}
 }
 
-func (kri *Kriging) Train(model ModelType, sigma2 float64, alpha float64) (*Kriging, error) {
+func (kri *Kriging) fitVariogram(model ModelType, alpha float64) error {
 	kri.nugget = 0.0
 	kri.rangex = 0.0
 	kri.sill = 0.0
 	kri.A = float64(1) / float64(3)
 	kri.n = 0.0
 
-	switch model {
-	case Gaussian:
-		kri.model = krigingKrigingGaussian
-	case Exponential:
-		kri.model = krigingKrigingExponential
-	case Spherical:
-		kri.model = krigingKrigingSpherical
-	}
+	pos, weights := kri.variogramSample()
 
 	var i, j, k, l, n int
-	n = len(kri.pos)
+	n = len(pos)
 
-	distance := make([][2]float64, (n*n-n)/2)
+	distance := make([][3]float64, (n*n-n)/2)
 
 	i = 0
 	k = 0
 	for ; i < n; i++ {
 		for j = 0; j < i; {
-			distance[k] = [2]float64{}
+			distance[k] = [3]float64{}
 			distance[k][0] = math.Pow(
-				math.Pow(kri.pos[i][0]-kri.pos[j][0], 2)+
-					math.Pow(kri.pos[i][1]-kri.pos[j][1], 2), 0.5)
-			distance[k][1] = math.Abs(kri.pos[i][2] - kri.pos[j][2])
+				math.Pow(pos[i][0]-pos[j][0], 2)+
+					math.Pow(pos[i][1]-pos[j][1], 2), 0.5)
+			distance[k][1] = math.Abs(pos[i][2] - pos[j][2])
+			distance[k][2] = 1
+			if len(weights) == n {
+				distance[k][2] = weights[i] * weights[j]
+			}
 			j++
 			k++
 		}
@@ -106,13 +119,15 @@ func (kri *Kriging) Train(model ModelType, sigma2 float64, alpha float64) (*Krig
 		j = 0
 		k = 0
 		l = 0
+		var w float64
 		for i < lags && j < ((n*n-n)/2) {
 			for {
 				if distance[j][0] > (float64(i+1) * tolerance) {
 					break
 				}
-				lag[l] += distance[j][0]
-				semi[l] += distance[j][1]
+				lag[l] += distance[j][2] * distance[j][0]
+				semi[l] += distance[j][2] * distance[j][1]
+				w += distance[j][2]
 				j++
 				k++
 				if j >= ((n*n - n) / 2) {
@@ -120,20 +135,44 @@ func (kri *Kriging) Train(model ModelType, sigma2 float64, alpha float64) (*Krig
 				}
 			}
 
-			if k > 0 {
-				lag[l] = lag[l] / float64(k)
-				semi[l] = semi[l] / float64(k)
+			if k > 0 && w > 0 {
+				lag[l] = lag[l] / w
+				semi[l] = semi[l] / w
 				l++
+			} else {
+				lag[l] = 0
+				semi[l] = 0
 			}
 			i++
 			k = 0
+			w = 0
 		}
 		if l < 2 {
-			return nil, errors.New("not enough points")
+			return errors.New("not enough points")
 		}
 	}
 
-	n = l
+	return kri.fitLags(model, lag[:l], semi[:l], alpha)
+}
+
+func (kri *Kriging) fitLags(model ModelType, lag, semi []float64, alpha float64) error {
+	kri.A = float64(1) / float64(3)
+
+	switch model {
+	case Gaussian:
+		kri.model = krigingKrigingGaussian
+	case Exponential:
+		kri.model = krigingKrigingExponential
+	case Spherical:
+		kri.model = krigingKrigingSpherical
+	}
+
+	var i int
+	n := len(lag)
+	if n < 2 {
+		return errors.New("not enough points")
+	}
+
 	kri.rangex = lag[n-1] - lag[0]
 	X := make([]float64, 2*n)
 	for i := 0; i < len(X); i++ {
@@ -156,12 +195,9 @@ func (kri *Kriging) Train(model ModelType, sigma2 float64, alpha float64) (*Krig
 	var Xt = matrixTranspose(X, n, 2)
 	var Z = matrixMultiply(Xt, X, 2, n, 2)
 	Z = matrixAdd(Z, matrixDiag(float64(1)/alpha, 2), 2, 2)
-	var cloneZ = make([]float64, len(Z))
-	copy(cloneZ, Z)
-	if matrixChol(Z, 2) {
-		matrixChol2inv(Z, 2)
-	} else {
-		Z, _ = matrixInverse(cloneZ, 2)
+	Z, err := matrixInverse(Z, 2)
+	if err != nil {
+		return err
 	}
 
 	var W = matrixMultiply(matrixMultiply(Z, Xt, 2, 2, n), Y, 2, n, 1)
@@ -170,7 +206,56 @@ func (kri *Kriging) Train(model ModelType, sigma2 float64, alpha float64) (*Krig
 	kri.sill = W[1]*kri.rangex + kri.nugget
 	kri.n = len(kri.pos)
 
-	n = len(kri.pos)
+	return nil
+}
+
+func (kri *Kriging) Train(model ModelType, sigma2 float64, alpha float64) (*Kriging, error) {
+	if err := kri.fitVariogram(model, alpha); err != nil {
+		return nil, err
+	}
+	return kri.solve(sigma2)
+}
+
+func (kri *Kriging) local(indices []int) *Kriging {
+	sub := &Kriging{
+		nugget: kri.nugget,
+		rangex: kri.rangex,
+		sill:   kri.sill,
+		A:      kri.A,
+		n:      len(indices),
+		model:  kri.model,
+		solver: kri.solver,
+		pos:    make([]vec3d.T, len(indices)),
+	}
+	if len(kri.variance) == len(kri.pos) {
+		sub.variance = make([]float64, len(indices))
+	}
+	if len(kri.weights) == len(kri.pos) {
+		sub.weights = make([]float64, len(indices))
+	}
+	for k, i := range indices {
+		sub.pos[k] = kri.pos[i]
+		if sub.variance != nil {
+			sub.variance[k] = kri.variance[i]
+		}
+		if sub.weights != nil {
+			sub.weights[k] = kri.weights[i]
+		}
+	}
+	return sub
+}
+
+func (kri *Kriging) solve(sigma2 float64) (*Kriging, error) {
+	kri.approx = nil
+	if kri.solver.Type != "" && kri.solver.Type != DenseSolver {
+		if err := kri.trainApprox(sigma2); err != nil {
+			return nil, err
+		}
+		return kri, nil
+	}
+
+	var i, j int
+	n := len(kri.pos)
 	K := make([]float64, n*n)
 	for i = 0; i < n; i++ {
 		for j = 0; j < i; j++ {
@@ -190,13 +275,14 @@ func (kri *Kriging) Train(model ModelType, sigma2 float64, alpha float64) (*Krig
 	}
 
 	var C = matrixAdd(K, matrixDiag(sigma2, n), n, n)
-	var cloneC = make([]float64, len(C))
-	copy(cloneC, C)
-	if matrixChol(C, n) {
-		matrixChol2inv(C, n)
-	} else {
-		matrixSolve(cloneC, n)
-		C = cloneC
+	if len(kri.variance) == n {
+		for i = 0; i < n; i++ {
+			C[i*n+i] += kri.variance[i]
+		}
+	}
+	C, err := matrixInverse(C, n)
+	if err != nil {
+		return nil, err
 	}
 
 	K = C
@@ -214,6 +300,10 @@ func (kri *Kriging) Train(model ModelType, sigma2 float64, alpha float64) (*Krig
 }
 
 func (kri *Kriging) Predict(x, y float64) float64 {
+	if kri.approx != nil {
+		return kri.predictApprox(x, y)
+	}
+
 	k := make([]float64, kri.n)
 	for i := 0; i < kri.n; i++ {
 		x_ := x - kri.pos[i][0]
@@ -262,3 +352,23 @@ func (kri *Kriging) Contour(xWidth, yWidth int) *ContourRectangle {
 
 	return contourRectangle
 }
+
+func (kri *Kriging) variogram(h float64) float64 {
+	return kri.model(h, kri.nugget, kri.rangex, kri.sill, kri.A)
+}
+
+func (kri *Kriging) covariance(h float64) float64 {
+	c0 := kri.variogram(math.Inf(1))
+	if h == 0 {
+		return c0
+	}
+	return c0 - kri.variogram(h)
+}
+
+func (kri *Kriging) continuousCovariance(h float64) float64 {
+	return kri.variogram(math.Inf(1)) - kri.variogram(h)
+}
+
+func (kri *Kriging) nuggetEffect() float64 {
+	return math.Max(kri.nugget, 0)
+}
@@ -0,0 +1,80 @@
+package kriging
+
+import (
+	"math"
+	"testing"
+
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/general"
+
+	vec3d "github.com/flywave/go3d/float64/vec3"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func krigingPoints() []vec3d.T {
+	pos := []vec3d.T{}
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 8; j++ {
+			x, y := float64(i)*10, float64(j)*10
+			pos = append(pos, vec3d.T{x, y, 10 + x/10 + math.Cos(y/20)})
+		}
+	}
+	return pos
+}
+
+func TestTrainVariance(t *testing.T) {
+	a := assert.New(t)
+
+	pos := krigingPoints()
+	pos[27][2] += 20
+
+	exact, err := New(pos).Train(Exponential, 0, 100)
+	a.Nil(err)
+
+	variance := make([]float64, len(pos))
+	variance[27] = 1000
+	noisy, err := NewWithVariance(pos, variance).Train(Exponential, 0, 100)
+	a.Nil(err)
+
+	x, y := pos[27][0], pos[27][1]
+	a.InDelta(pos[27][2], exact.Predict(x, y), 1e-3)
+	a.True(noisy.Predict(x, y) < pos[27][2]-10)
+	a.InDelta(pos[0][2], noisy.Predict(pos[0][0], pos[0][1]), 1e-3)
+}
+
+func TestVarianceProperty(t *testing.T) {
+	a := assert.New(t)
+
+	fc := geom.NewFeatureCollection()
+	for _, v := range []interface{}{0.5, nil, "n/a", -1.0, "0.25"} {
+		fea := geom.NewFeature(general.NewPoint3([]float64{1, 2, 3}))
+		if v != nil {
+			fea.Properties["sigma"] = v
+		}
+		fc.AddFeature(fea)
+	}
+
+	prop := "sigma"
+	p := NewKrigingInterpolator(Options{Input: fc, VarianceProperty: &prop})
+	pos, variance := p.extractPosion()
+	a.Equal([]vec3d.T{{1, 2, 3}, {1, 2, 3}}, pos)
+	a.Equal([]float64{0.5, 0.25}, variance)
+	a.Len(p.report.Skipped, 3)
+}
+
+func TestCovariance(t *testing.T) {
+	a := assert.New(t)
+
+	kri := &Kriging{model: krigingKrigingExponential, nugget: 0.5, rangex: 10, sill: 2, A: 1.0 / 3}
+	c0 := kri.variogram(math.Inf(1))
+	a.Equal(c0, kri.covariance(0))
+	a.InDelta(c0-kri.nugget, kri.covariance(1e-12), 1e-9)
+	a.InDelta(c0-kri.variogram(5), kri.covariance(5), 1e-12)
+	a.Less(kri.covariance(5), kri.covariance(1))
+	a.Equal(c0, kri.continuousCovariance(0)+kri.nuggetEffect())
+
+	kri.nugget = -1
+	a.Equal(kri.variogram(math.Inf(1)), kri.covariance(0))
+	a.Equal(0.0, kri.nuggetEffect())
+}
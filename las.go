@@ -0,0 +1,270 @@
+package kriging
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/flywave/go-geo"
+
+	vec2d "github.com/flywave/go3d/float64/vec2"
+	vec3d "github.com/flywave/go3d/float64/vec3"
+)
+
+const (
+	default_las_header_size  = 227
+	default_las_vlr_size     = 54
+	default_las_compressed   = 0xc0
+	default_las_geokey_user  = "LASF_Projection"
+	default_las_geokey_id    = 34735
+	default_las_wkt_id       = 2112
+	default_las_wkt_encoding = 0x10
+	default_las_projected_cs = 3072
+	default_las_geographic   = 2048
+)
+
+var lasRecordLength = [...]int{20, 28, 26, 34, 57, 63, 30, 36, 38, 59, 67}
+
+type LASOptions struct {
+	Classes      []uint8
+	Returns      []uint8
+	Bounds       *vec2d.Rect // in the coordinates stored in the file
+	KeepWithheld bool
+	Srs          *string
+}
+
+type LASHeader struct {
+	Version      [2]uint8
+	PointFormat  uint8
+	RecordLength uint16
+	Points       uint64
+	Scale        vec3d.T
+	Offset       vec3d.T
+	Min          vec3d.T
+	Max          vec3d.T
+	EPSG         int
+	WKT          string
+}
+
+type LASReader struct {
+	r      *bufio.Reader
+	header LASHeader
+	opts   LASOptions
+	proj   geo.Proj
+	record []byte
+	read   uint64
+	report Report
+}
+
+func NewLASReader(r io.Reader, opts LASOptions) (*LASReader, error) {
+	br := bufio.NewReader(r)
+	buf := make([]byte, default_las_header_size)
+	if _, err := io.ReadFull(br, buf); err != nil {
+		return nil, fmt.Errorf("las header: %w", err)
+	}
+	if string(buf[:4]) != "LASF" {
+		return nil, errors.New("not a LAS file")
+	}
+
+	le := binary.LittleEndian
+	h := LASHeader{
+		Version:      [2]uint8{buf[24], buf[25]},
+		PointFormat:  buf[104],
+		RecordLength: le.Uint16(buf[105:]),
+		Points:       uint64(le.Uint32(buf[107:])),
+	}
+	if h.Version[0] != 1 || h.Version[1] < 2 || h.Version[1] > 4 {
+		return nil, fmt.Errorf("unsupported LAS version %d.%d", h.Version[0], h.Version[1])
+	}
+	if h.PointFormat&default_las_compressed != 0 {
+		return nil, errors.New("LAZ compressed point clouds are not supported")
+	}
+	if int(h.PointFormat) >= len(lasRecordLength) {
+		return nil, fmt.Errorf("unsupported LAS point format %d", h.PointFormat)
+	}
+	if int(h.RecordLength) < lasRecordLength[h.PointFormat] {
+		return nil, fmt.Errorf("LAS point record length %d is too short for format %d", h.RecordLength, h.PointFormat)
+	}
+	for i := 0; i < 3; i++ {
+		h.Scale[i] = math.Float64frombits(le.Uint64(buf[131+8*i:]))
+		h.Offset[i] = math.Float64frombits(le.Uint64(buf[155+8*i:]))
+		h.Max[i] = math.Float64frombits(le.Uint64(buf[179+16*i:]))
+		h.Min[i] = math.Float64frombits(le.Uint64(buf[187+16*i:]))
+	}
+
+	headerSize := int(le.Uint16(buf[94:]))
+	pointOffset := int(le.Uint32(buf[96:]))
+	vlrs := int(le.Uint32(buf[100:]))
+	if headerSize < default_las_header_size || pointOffset < headerSize {
+		return nil, errors.New("invalid LAS header size")
+	}
+
+	ext := make([]byte, headerSize-default_las_header_size)
+	if _, err := io.ReadFull(br, ext); err != nil {
+		return nil, fmt.Errorf("las header: %w", err)
+	}
+	if h.Version[1] >= 4 && len(ext) >= 28 {
+		if n := le.Uint64(ext[20:]); n > 0 {
+			h.Points = n
+		}
+	}
+
+	pos := headerSize
+	for i := 0; i < vlrs && pos+default_las_vlr_size <= pointOffset; i++ {
+		vh := make([]byte, default_las_vlr_size)
+		if _, err := io.ReadFull(br, vh); err != nil {
+			return nil, fmt.Errorf("las vlr: %w", err)
+		}
+		body := make([]byte, le.Uint16(vh[20:]))
+		if _, err := io.ReadFull(br, body); err != nil {
+			return nil, fmt.Errorf("las vlr: %w", err)
+		}
+		pos += default_las_vlr_size + len(body)
+
+		user := strings.TrimRight(string(vh[2:18]), "\x00")
+		if user != default_las_geokey_user {
+			continue
+		}
+		switch le.Uint16(vh[18:]) {
+		case default_las_geokey_id:
+			h.EPSG = lasGeoKeyEPSG(body)
+		case default_las_wkt_id:
+			h.WKT = strings.TrimRight(string(body), "\x00")
+		}
+	}
+	if _, err := br.Discard(pointOffset - pos); err != nil {
+		return nil, fmt.Errorf("las point data: %w", err)
+	}
+
+	reader := &LASReader{r: br, header: h, opts: opts, record: make([]byte, h.RecordLength)}
+	switch {
+	case opts.Srs != nil:
+		reader.proj = geo.NewProj(*opts.Srs)
+	case h.WKT != "":
+		if reader.proj = wktProj(h.WKT); reader.proj == nil {
+			return nil, errors.New("cannot resolve the LAS WKT coordinate system, set Srs")
+		}
+	case le.Uint16(buf[6:])&default_las_wkt_encoding != 0:
+		return nil, errors.New("LAS WKT coordinate system is not stored in a VLR, set Srs")
+	case h.EPSG != 0:
+		reader.proj = geo.NewProj(h.EPSG)
+	}
+	return reader, nil
+}
+
+func lasGeoKeyEPSG(body []byte) int {
+	le := binary.LittleEndian
+	if len(body) < 8 {
+		return 0
+	}
+	keys := int(le.Uint16(body[6:]))
+	epsg := 0
+	for i := 0; i < keys && 8+8*i+8 <= len(body); i++ {
+		key := body[8+8*i:]
+		if le.Uint16(key[2:]) != 0 {
+			continue
+		}
+		switch le.Uint16(key) {
+		case default_las_projected_cs:
+			return int(le.Uint16(key[6:]))
+		case default_las_geographic:
+			epsg = int(le.Uint16(key[6:]))
+		}
+	}
+	return epsg
+}
+
+func (r *LASReader) Header() LASHeader {
+	return r.header
+}
+
+func lasContains(values []uint8, v uint8) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, c := range values {
+		if c == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *LASReader) accept(rec []byte, v vec3d.T) bool {
+	var class, ret uint8
+	var withheld bool
+	if r.header.PointFormat >= 6 {
+		class = rec[16]
+		ret = rec[14] & 0x0f
+		withheld = rec[15]&0x04 != 0
+	} else {
+		class = rec[15] & 0x1f
+		ret = rec[14] & 0x07
+		withheld = rec[15]&0x80 != 0
+	}
+	if withheld && !r.opts.KeepWithheld {
+		return false
+	}
+	if r.opts.Bounds != nil && !r.opts.Bounds.ContainsPoint(&vec2d.T{v[0], v[1]}) {
+		return false
+	}
+	return lasContains(r.opts.Classes, class) && lasContains(r.opts.Returns, ret)
+}
+
+func (r *LASReader) Read() (Sample, error) {
+	le := binary.LittleEndian
+	for r.read < r.header.Points {
+		if _, err := io.ReadFull(r.r, r.record); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return Sample{}, err
+		}
+		r.read++
+		r.report.Features++
+
+		var v vec3d.T
+		for i := 0; i < 3; i++ {
+			v[i] = float64(int32(le.Uint32(r.record[4*i:])))*r.header.Scale[i] + r.header.Offset[i]
+		}
+		if !r.accept(r.record, v) {
+			r.report.Filtered++
+			continue
+		}
+		if r.proj != nil && !r.proj.Eq(epsg4326) {
+			pos2 := r.proj.TransformTo(epsg4326, []vec2d.T{{v[0], v[1]}})
+			v[0], v[1] = pos2[0][0], pos2[0][1]
+		}
+		r.report.Points++
+		return Sample{X: v[0], Y: v[1], Value: v[2]}, nil
+	}
+	return Sample{}, io.EOF
+}
+
+func (r *LASReader) Report() Report {
+	return r.report
+}
+
+func (p *KrigingInterpolator) readLAS() ([]vec3d.T, []float64, error) {
+	f, err := os.Open(*p.inputLAS)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	opts := p.las
+	if opts.Srs == nil {
+		opts.Srs = p.inputSrs
+	}
+	reader, err := NewLASReader(f, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return p.readSource(reader)
+}
@@ -0,0 +1,225 @@
+package kriging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	vec2d "github.com/flywave/go3d/float64/vec2"
+	vec3d "github.com/flywave/go3d/float64/vec3"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type lasTestPoint struct {
+	x, y, z  int32
+	class    uint8
+	withheld bool
+	ret      uint8
+}
+
+func lasTestFile(minor, format uint8, points []lasTestPoint, epsg uint16) []byte {
+	le := binary.LittleEndian
+	headerSize := map[uint8]int{2: 227, 3: 235, 4: 375}[minor]
+	recordLength := lasRecordLength[format]
+
+	vlr := []byte{}
+	if epsg != 0 {
+		body := make([]byte, 16)
+		for i, v := range []uint16{1, 1, 0, 1, default_las_projected_cs, 0, 1, epsg} {
+			le.PutUint16(body[2*i:], v)
+		}
+		vlr = make([]byte, default_las_vlr_size)
+		copy(vlr[2:], default_las_geokey_user)
+		le.PutUint16(vlr[18:], default_las_geokey_id)
+		le.PutUint16(vlr[20:], uint16(len(body)))
+		vlr = append(vlr, body...)
+	}
+
+	buf := make([]byte, headerSize)
+	copy(buf, "LASF")
+	buf[24], buf[25] = 1, minor
+	le.PutUint16(buf[94:], uint16(headerSize))
+	le.PutUint32(buf[96:], uint32(headerSize+len(vlr)))
+	if epsg != 0 {
+		le.PutUint32(buf[100:], 1)
+	}
+	buf[104] = format
+	le.PutUint16(buf[105:], uint16(recordLength))
+	if minor < 4 {
+		le.PutUint32(buf[107:], uint32(len(points)))
+	} else {
+		le.PutUint64(buf[247:], uint64(len(points)))
+	}
+	for i, v := range []float64{0.01, 0.01, 0.1, 100, 200, 0} {
+		le.PutUint64(buf[131+8*i:], math.Float64bits(v))
+	}
+	buf = append(buf, vlr...)
+
+	for _, p := range points {
+		rec := make([]byte, recordLength)
+		le.PutUint32(rec[0:], uint32(p.x))
+		le.PutUint32(rec[4:], uint32(p.y))
+		le.PutUint32(rec[8:], uint32(p.z))
+		rec[14] = p.ret
+		if format >= 6 {
+			rec[16] = p.class
+			if p.withheld {
+				rec[15] = 0x04
+			}
+		} else {
+			rec[15] = p.class
+			if p.withheld {
+				rec[15] |= 0x80
+			}
+		}
+		buf = append(buf, rec...)
+	}
+	return buf
+}
+
+func TestLASReader(t *testing.T) {
+	a := assert.New(t)
+
+	points := []lasTestPoint{{100, 200, 30, 2, false, 1}, {-100, 0, 10, 1, false, 2}, {0, 0, 0, 2, true, 1}, {50, 50, 50, 2, false, 2}}
+	for _, version := range [][2]uint8{{2, 1}, {3, 3}, {4, 6}, {4, 10}} {
+		data := lasTestFile(version[0], version[1], points, 32633)
+		r, err := NewLASReader(bytes.NewReader(data), LASOptions{Classes: []uint8{2}})
+		a.Nil(err)
+
+		h := r.Header()
+		a.Equal(uint64(4), h.Points)
+		a.Equal(version[1], h.PointFormat)
+		a.Equal(32633, h.EPSG)
+
+		r.proj = nil
+		pos := []vec3d.T{}
+		for {
+			p, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+			a.Nil(err)
+			pos = append(pos, vec3d.T{p.X, p.Y, p.Value})
+		}
+		a.Len(pos, 2)
+		a.InDelta(101, pos[0][0], 1e-9)
+		a.InDelta(202, pos[0][1], 1e-9)
+		a.InDelta(3, pos[0][2], 1e-9)
+		a.InDelta(100.5, pos[1][0], 1e-9)
+
+		report := r.Report()
+		a.Equal(4, report.Features)
+		a.Equal(2, report.Points)
+		a.Equal(2, report.Filtered)
+	}
+
+	r, err := NewLASReader(bytes.NewReader(lasTestFile(2, 0, points, 0)), LASOptions{KeepWithheld: true})
+	a.Nil(err)
+	a.Equal(0, r.Header().EPSG)
+	for i := 0; i < 4; i++ {
+		_, err = r.Read()
+		a.Nil(err)
+	}
+	_, err = r.Read()
+	a.Equal(io.EOF, err)
+
+	laz := lasTestFile(2, 1, points, 0)
+	laz[104] |= 0x80
+	_, err = NewLASReader(bytes.NewReader(laz), LASOptions{})
+	a.NotNil(err)
+
+	truncated := lasTestFile(2, 1, points, 0)
+	r, err = NewLASReader(bytes.NewReader(truncated[:len(truncated)-5]), LASOptions{})
+	a.Nil(err)
+	for err == nil {
+		_, err = r.Read()
+	}
+	a.Equal(io.ErrUnexpectedEOF, err)
+
+	_, err = NewLASReader(bytes.NewReader([]byte("not a las file")), LASOptions{})
+	a.NotNil(err)
+
+	for _, version := range [][2]uint8{{2, 1}, {4, 6}} {
+		data := lasTestFile(version[0], version[1], points, 32633)
+		r, err = NewLASReader(bytes.NewReader(data), LASOptions{Returns: []uint8{2}, KeepWithheld: true})
+		a.Nil(err)
+		a.Equal("EPSG:32633", r.proj.GetSrsCode())
+		r.proj = nil
+		samples := readSamples(a, r)
+		a.Len(samples, 2)
+		a.InDelta(99, samples[0].X, 1e-9)
+		a.InDelta(100.5, samples[1].X, 1e-9)
+
+		srs := "EPSG:3857"
+		bounds := vec2d.Rect{Min: vec2d.T{100, 200}, Max: vec2d.T{100.6, 200.6}}
+		r, err = NewLASReader(bytes.NewReader(data), LASOptions{Bounds: &bounds, KeepWithheld: true, Srs: &srs})
+		a.Nil(err)
+		a.Equal("EPSG:3857", r.proj.GetSrsCode())
+		r.proj = nil
+		samples = readSamples(a, r)
+		a.Len(samples, 2)
+		a.InDelta(100, samples[0].X, 1e-9)
+		a.InDelta(200.5, samples[1].Y, 1e-9)
+		a.Equal(2, r.Report().Filtered)
+	}
+}
+
+func lasWithWKT(data []byte, wkt string, encoding bool) []byte {
+	le := binary.LittleEndian
+	headerSize := int(le.Uint16(data[94:]))
+	if encoding {
+		le.PutUint16(data[6:], default_las_wkt_encoding)
+	}
+	if wkt == "" {
+		return data
+	}
+
+	body := append([]byte(wkt), 0)
+	vlr := make([]byte, default_las_vlr_size)
+	copy(vlr[2:], default_las_geokey_user)
+	le.PutUint16(vlr[18:], default_las_wkt_id)
+	le.PutUint16(vlr[20:], uint16(len(body)))
+	vlr = append(vlr, body...)
+
+	out := append(append(append([]byte{}, data[:headerSize]...), vlr...), data[headerSize:]...)
+	le.PutUint32(out[96:], le.Uint32(out[96:])+uint32(len(vlr)))
+	le.PutUint32(out[100:], le.Uint32(out[100:])+1)
+	return out
+}
+
+func TestLASCoordinateSystem(t *testing.T) {
+	a := assert.New(t)
+
+	points := []lasTestPoint{{100, 200, 30, 2, false, 1}, {-100, 0, 10, 1, false, 2}}
+	wkt := `PROJCS["WGS 84 / UTM zone 50N",GEOGCS["WGS 84"],AUTHORITY["EPSG","32650"]]`
+	r, err := NewLASReader(bytes.NewReader(lasWithWKT(lasTestFile(4, 6, points, 32633), wkt, true)), LASOptions{})
+	a.Nil(err)
+	a.Equal(wkt, r.Header().WKT)
+	a.Equal("EPSG:32650", r.proj.GetSrsCode())
+	a.Len(readSamples(a, r), 2)
+
+	srs := "EPSG:3857"
+	for _, data := range [][]byte{
+		lasWithWKT(lasTestFile(4, 6, points, 0), "", true),
+		lasWithWKT(lasTestFile(4, 6, points, 0), `LOCAL_CS["unknown"]`, true),
+	} {
+		_, err = NewLASReader(bytes.NewReader(data), LASOptions{})
+		a.NotNil(err)
+		r, err = NewLASReader(bytes.NewReader(data), LASOptions{Srs: &srs})
+		a.Nil(err)
+		a.Equal(srs, r.proj.GetSrsCode())
+	}
+
+	path := filepath.Join(t.TempDir(), "points.las")
+	a.Nil(os.WriteFile(path, lasTestFile(2, 1, points, 32633), 0644))
+	p := NewKrigingInterpolator(Options{InputLAS: &path, InputSrs: &srs})
+	pos, _, err := p.readInput()
+	a.Nil(err)
+	a.Len(pos, 2)
+	a.InDelta(101/(6378137*math.Pi/180), pos[0][0], 1e-9)
+}
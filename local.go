@@ -0,0 +1,77 @@
+package kriging
+
+import (
+	"math"
+
+	vec3d "github.com/flywave/go3d/float64/vec3"
+)
+
+func (kri *Kriging) simpleKrige(pos []vec3d.T, nb []neighbour, x, y, mean float64) (float64, float64, error) {
+	n := len(nb)
+	c0 := kri.continuousCovariance(0) + kri.nuggetEffect()
+	if n == 0 {
+		return mean, c0, nil
+	}
+	if nb[0].dist == 0 {
+		return pos[nb[0].index][2], 0, nil
+	}
+
+	C := make([]float64, n*n)
+	c := make([]float64, n)
+	for i := 0; i < n; i++ {
+		pi := pos[nb[i].index]
+		for j := 0; j < i; j++ {
+			pj := pos[nb[j].index]
+			C[i*n+j] = kri.continuousCovariance(math.Hypot(pi[0]-pj[0], pi[1]-pj[1]))
+			C[j*n+i] = C[i*n+j]
+		}
+		C[i*n+i] = c0
+		c[i] = kri.continuousCovariance(nb[i].dist)
+	}
+
+	w, err := matrixSolveVec(C, c, n)
+	if err != nil {
+		return mean, c0, err
+	}
+
+	est := mean
+	variance := c0
+	for i := 0; i < n; i++ {
+		est += w[i] * (pos[nb[i].index][2] - mean)
+		variance -= w[i] * c[i]
+	}
+	if variance < 0 {
+		variance = 0
+	}
+	return est, variance, nil
+}
+
+type localKriging struct {
+	kriging    *Kriging
+	index      *pointIndex
+	barrier    *breaklineIndex
+	mean       float64
+	neighbours int
+}
+
+func (kri *Kriging) localPredictor(neighbours int, barrier *breaklineIndex) *localKriging {
+	return &localKriging{
+		kriging:    kri,
+		index:      newPointIndex(kri.pos, suggestCellSize(kri.pos, 4)),
+		barrier:    barrier,
+		mean:       kri.Statistics().Mean,
+		neighbours: neighbours,
+	}
+}
+
+func (l *localKriging) Predict(x, y float64) float64 {
+	nb := visibleNeighbours(l.index, l.barrier, x, y, l.neighbours, 0)
+	if len(nb) == 0 {
+		return math.NaN()
+	}
+	est, _, err := l.kriging.simpleKrige(l.kriging.pos, nb, x, y, l.mean)
+	if err != nil {
+		return math.NaN()
+	}
+	return est
+}
@@ -0,0 +1,118 @@
+package kriging
+
+import (
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/general"
+
+	vec2d "github.com/flywave/go3d/float64/vec2"
+)
+
+type maskPolygon struct {
+	rings [][]vec2d.T
+	rect  vec2d.Rect
+}
+
+func newMaskPolygon(rings [][]vec2d.T) maskPolygon {
+	rect := vec2d.Rect{Min: vec2d.MaxVal, Max: vec2d.MinVal}
+	for _, ring := range rings {
+		for i := range ring {
+			rect.Extend(&ring[i])
+		}
+	}
+	return maskPolygon{rings: rings, rect: rect}
+}
+
+func (m *maskPolygon) Contains(p vec2d.T) bool {
+	if p[0] < m.rect.Min[0] || p[0] > m.rect.Max[0] || p[1] < m.rect.Min[1] || p[1] > m.rect.Max[1] {
+		return false
+	}
+	inside := false
+	for _, ring := range m.rings {
+		for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+			a, b := ring[i], ring[j]
+			if (a[1] > p[1]) != (b[1] > p[1]) && p[0] < (b[0]-a[0])*(p[1]-a[1])/(b[1]-a[1])+a[0] {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+type Mask struct {
+	clip    []maskPolygon
+	exclude []maskPolygon
+}
+
+func NewMask(clip, exclude [][][]vec2d.T) *Mask {
+	m := &Mask{}
+	for _, rings := range clip {
+		m.clip = append(m.clip, newMaskPolygon(rings))
+	}
+	for _, rings := range exclude {
+		m.exclude = append(m.exclude, newMaskPolygon(rings))
+	}
+	return m
+}
+
+func (m *Mask) Contains(p vec2d.T) bool {
+	if m == nil {
+		return true
+	}
+	if len(m.clip) > 0 {
+		inside := false
+		for i := range m.clip {
+			if m.clip[i].Contains(p) {
+				inside = true
+				break
+			}
+		}
+		if !inside {
+			return false
+		}
+	}
+	for i := range m.exclude {
+		if m.exclude[i].Contains(p) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *KrigingInterpolator) maskPolygons(fc *geom.FeatureCollection) [][][]vec2d.T {
+	if fc == nil {
+		return nil
+	}
+	polygon := func(data [][][]float64) [][]vec2d.T {
+		rings := make([][]vec2d.T, 0, len(data))
+		for _, coords := range data {
+			ring := make([]vec2d.T, len(coords))
+			for i := range coords {
+				ring[i] = vec2d.T{coords[i][0], coords[i][1]}
+			}
+			if p.inputProj != nil && !p.inputProj.Eq(epsg4326) {
+				ring = p.inputProj.TransformTo(epsg4326, ring)
+			}
+			rings = append(rings, ring)
+		}
+		return rings
+	}
+
+	ret := [][][]vec2d.T{}
+	for _, feas := range fc.Features {
+		switch g := feas.Geometry.(type) {
+		case *general.Polygon:
+			ret = append(ret, polygon(g.Data()))
+		case *general.Polygon3:
+			ret = append(ret, polygon(g.Data()))
+		case *general.MultiPolygon:
+			for _, data := range g.Data() {
+				ret = append(ret, polygon(data))
+			}
+		case *general.MultiPolygon3:
+			for _, data := range g.Data() {
+				ret = append(ret, polygon(data))
+			}
+		}
+	}
+	return ret
+}
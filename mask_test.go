@@ -0,0 +1,49 @@
+package kriging
+
+import (
+	"testing"
+
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/general"
+
+	vec2d "github.com/flywave/go3d/float64/vec2"
+	vec3d "github.com/flywave/go3d/float64/vec3"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMask(t *testing.T) {
+	a := assert.New(t)
+
+	clip := geom.NewFeatureCollection()
+	clip.AddFeature(geom.NewFeature(general.NewPolygon([][][]float64{
+		{{0, 0}, {100, 0}, {100, 100}, {0, 100}, {0, 0}},
+		{{40, 40}, {60, 40}, {60, 60}, {40, 60}, {40, 40}},
+	})))
+	exclude := geom.NewFeatureCollection()
+	exclude.AddFeature(geom.NewFeature(general.NewPolygon([][][]float64{
+		{{80, 80}, {90, 80}, {90, 90}, {80, 90}, {80, 80}},
+	})))
+
+	pos := []vec3d.T{{-10, -10, 1}, {110, -10, 1}, {110, 110, 1}, {-10, 110, 1}}
+	p := NewKrigingInterpolator(Options{Clip: clip, Exclude: exclude})
+	p.inputPos = pos
+	a.Nil(p.computeBoundary())
+	p.computeMask()
+
+	for pt, want := range map[vec2d.T]bool{
+		{10, 10}:  true,
+		{50, 50}:  false,
+		{70, 50}:  true,
+		{85, 85}:  false,
+		{105, 50}: false,
+	} {
+		a.Equal(want, p.mask.Contains(pt), pt)
+	}
+
+	grid := &Grid{Coordinates: Coordinates{{10, 10, 0}, {50, 50, 0}, {85, 85, 0}, {105, 50, 0}, {200, 200, 0}}}
+	a.Nil(p.resample(grid, func(x, y float64) float64 { return 1 }))
+	for i, want := range []float64{1, default_no_data, default_no_data, default_no_data, default_no_data} {
+		a.Equal(want, grid.Coordinates[i][2])
+	}
+}
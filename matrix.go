@@ -1,9 +1,28 @@
 package kriging
 
 import (
+	"fmt"
 	"math"
+
+	"gonum.org/v1/gonum/blas"
+	"gonum.org/v1/gonum/blas/blas64"
+	"gonum.org/v1/gonum/lapack"
+	"gonum.org/v1/gonum/lapack/lapack64"
+	"gonum.org/v1/gonum/mat"
 )
 
+type ConditionError struct {
+	Size int
+	Cond float64
+}
+
+func (e *ConditionError) Error() string {
+	if math.IsInf(e.Cond, 1) || math.IsNaN(e.Cond) {
+		return fmt.Sprintf("%dx%d linear system is singular", e.Size, e.Size)
+	}
+	return fmt.Sprintf("%dx%d linear system is ill-conditioned (condition number %.3g)", e.Size, e.Size, e.Cond)
+}
+
 func matrixTranspose(X []float64, n, m int) []float64 {
 	Z := make([]float64, m*n)
 	for i := 0; i < n; i++ {
@@ -16,20 +35,9 @@ func matrixTranspose(X []float64, n, m int) []float64 {
 }
 
 func matrixMultiply(X, Y []float64, n, m, p int) []float64 {
-	Z := make([]float64, n*p)
-	for i := 0; i < n; i++ {
-		for j := 0; j < p; j++ {
-			Z[i*p+j] = 0
-			for k := 0; k < m; k++ {
-				x := X[i*m+k]
-				if x == 0 {
-					x = 1.0 / float64(m)
-				}
-				Z[i*p+j] += x * Y[k*p+j]
-			}
-		}
-	}
-	return Z
+	var Z mat.Dense
+	Z.Mul(mat.NewDense(n, m, X), mat.NewDense(m, p, Y))
+	return Z.RawMatrix().Data
 }
 
 func matrixAdd(X, Y []float64, n, m int) []float64 {
@@ -51,165 +59,113 @@ func matrixDiag(c float64, n int) []float64 {
 	return Z
 }
 
-func matrixChol(X []float64, n int) bool {
-	p := make([]float64, n)
-
-	for i := 0; i < n; i++ {
-		p[i] = X[i*n+i]
-	}
-
+func matrixIsSymmetric(X []float64, n int) bool {
 	for i := 0; i < n; i++ {
 		for j := 0; j < i; j++ {
-			p[i] -= X[i*n+j] * X[i*n+j]
-		}
-		if p[i] <= 0 {
-			return false
-		}
-		p[i] = math.Sqrt(p[i])
-		for j := i + 1; j < n; j++ {
-			for k := 0; k < i; k++ {
-				X[j*n+i] -= X[j*n+k] * X[i*n+k]
-				X[j*n+i] /= p[i]
+			if X[i*n+j] != X[j*n+i] {
+				return false
 			}
 		}
 	}
+	return true
+}
 
-	for i := 0; i < n; i++ {
-		X[i*n+i] = p[i]
+func matrixCholesky(X []float64, n int) (*mat.Cholesky, bool, error) {
+	if !matrixIsSymmetric(X, n) {
+		return nil, false, nil
 	}
-	return true
+	var chol mat.Cholesky
+	if !chol.Factorize(mat.NewSymDense(n, append([]float64(nil), X...))) {
+		return nil, false, nil
+	}
+	if cond := chol.Cond(); cond > mat.ConditionTolerance || math.IsNaN(cond) {
+		return nil, true, &ConditionError{Size: n, Cond: cond}
+	}
+	return &chol, true, nil
 }
 
-func matrixChol2inv(X []float64, n int) {
-	var i, j, k int
-	var sum float64
+type luFactor struct {
+	n    int
+	a    blas64.General
+	ipiv []int
+}
 
-	for i = 0; i < n; i++ {
-		X[i*n+i] = 1 / X[i*n+i]
-		for j = i + 1; j < n; j++ {
-			sum = 0
-			for k = i; k < j; k++ {
-				sum -= X[j*n+k] * X[k*n+i]
-			}
-			X[j*n+i] = sum / X[j*n+j]
-		}
-	}
+func matrixLU(X []float64, n int) (*luFactor, error) {
+	a := blas64.General{Rows: n, Cols: n, Stride: n, Data: append([]float64(nil), X...)}
+	work := make([]float64, 4*n)
+	anorm := lapack64.Lange(lapack.MaxColumnSum, a, work)
 
-	for i = 0; i < n; i++ {
-		for j = i + 1; j < n; j++ {
-			X[i*n+j] = 0
-		}
+	ipiv := make([]int, n)
+	if !lapack64.Getrf(a, ipiv) {
+		return nil, &ConditionError{Size: n, Cond: math.Inf(1)}
+	}
+	cond := 1 / lapack64.Gecon(lapack.MaxColumnSum, a, anorm, work, make([]int, n))
+	if cond > mat.ConditionTolerance || math.IsNaN(cond) {
+		return nil, &ConditionError{Size: n, Cond: cond}
 	}
+	return &luFactor{n: n, a: a, ipiv: ipiv}, nil
+}
 
-	for i = 0; i < n; i++ {
-		X[i*n+i] *= X[i*n+i]
-		for k = i + 1; k < n; k++ {
-			X[i*n+i] += X[k*n+i] * X[k*n+i]
-		}
+func (lu *luFactor) solve(B []float64, m int) []float64 {
+	b := blas64.General{Rows: lu.n, Cols: m, Stride: m, Data: append([]float64(nil), B...)}
+	lapack64.Getrs(blas.NoTrans, lu.a, b, lu.ipiv)
+	return b.Data
+}
 
-		for j = i + 1; j < n; j++ {
-			for k = j; k < n; k++ {
-				X[i*n+j] += X[k*n+i] * X[k*n+j]
+func matrixInverse(X []float64, n int) ([]float64, error) {
+	chol, ok, err := matrixCholesky(X, n)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		var inv mat.SymDense
+		if err := chol.InverseTo(&inv); err != nil {
+			return nil, &ConditionError{Size: n, Cond: chol.Cond()}
+		}
+		Z := make([]float64, n*n)
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				Z[i*n+j] = inv.At(i, j)
 			}
 		}
+		return Z, nil
 	}
 
-	for i = 0; i < n; i++ {
-		for j = 0; j < i; j++ {
-			X[i*n+j] = X[j*n+i]
-		}
+	lu, err := matrixLU(X, n)
+	if err != nil {
+		return nil, err
 	}
+	return lu.solve(matrixDiag(1, n), n), nil
 }
 
-func matrixSolve(X []float64, n int) bool {
-	var m = n
-	var b = make([]float64, n*n)
-	var indxc = make([]int, n)
-	var indxr = make([]int, n)
-	var ipiv = make([]int, n)
-	var i, icol, irow, j, k, l, ll int
-	var big, dum, pivinv, temp float64
-
-	for i = 0; i < n; i++ {
-		for j = 0; j < n; j++ {
-			if i == j {
-				b[i*n+j] = 1
-			} else {
-				b[i*n+j] = 0
+func matrixFactorize(X []float64, n int) (func(b []float64) ([]float64, error), error) {
+	chol, ok, err := matrixCholesky(X, n)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return func(b []float64) ([]float64, error) {
+			var r mat.VecDense
+			if err := chol.SolveVecTo(&r, mat.NewVecDense(n, b)); err != nil {
+				return nil, &ConditionError{Size: n, Cond: chol.Cond()}
 			}
-		}
+			return r.RawVector().Data, nil
+		}, nil
 	}
 
-	for j = 0; j < n; j++ {
-		ipiv[j] = 0
+	lu, err := matrixLU(X, n)
+	if err != nil {
+		return nil, err
 	}
+	return func(b []float64) ([]float64, error) {
+		return lu.solve(b, 1), nil
+	}, nil
+}
 
-	for i = 0; i < n; i++ {
-		big = 0
-		for j = 0; j < n; j++ {
-			if ipiv[j] != 1 {
-				for k = 0; k < n; k++ {
-					if ipiv[k] == 0 {
-						if math.Abs(X[j*n+k]) >= big {
-							big = math.Abs(X[j*n+k])
-							irow = j
-							icol = k
-						}
-					}
-				}
-			}
-		}
-		ipiv[icol]++
-		if irow != icol {
-			for l = 0; l < n; l++ {
-				temp = X[irow*n+l]
-				X[irow*n+l] = X[icol*n+l]
-				X[icol*n+l] = temp
-			}
-			for l = 0; l < m; l++ {
-				temp = b[irow*n+l]
-				b[irow*n+l] = b[icol*n+l]
-				b[icol*n+l] = temp
-			}
-		}
-		indxr[i] = irow
-		indxc[i] = icol
-
-		if X[icol*n+icol] == 0 {
-			return false
-		} // Singular
-
-		pivinv = 1 / X[icol*n+icol]
-		X[icol*n+icol] = 1
-		for l = 0; l < n; l++ {
-			X[icol*n+l] *= pivinv
-		}
-		for l = 0; l < m; l++ {
-			b[icol*n+l] *= pivinv
-		}
-
-		for ll = 0; ll < n; ll++ {
-			if ll != icol {
-				dum = X[ll*n+icol]
-				X[ll*n+icol] = 0
-				for l = 0; l < n; l++ {
-					X[ll*n+l] -= X[icol*n+l] * dum
-				}
-				for l = 0; l < m; l++ {
-					b[ll*n+l] -= b[icol*n+l] * dum
-				}
-			}
-		}
-	}
-	for l = n - 1; l >= 0; l-- {
-		if indxr[l] != indxc[l] {
-			for k = 0; k < n; k++ {
-				temp = X[k*n+indxr[l]]
-				X[k*n+indxr[l]] = X[k*n+indxc[l]]
-				X[k*n+indxc[l]] = temp
-			}
-		}
+func matrixSolveVec(X, b []float64, n int) ([]float64, error) {
+	solve, err := matrixFactorize(X, n)
+	if err != nil {
+		return nil, err
 	}
-
-	return true
+	return solve(b)
 }
@@ -0,0 +1,64 @@
+package kriging
+
+import (
+	"errors"
+	"testing"
+
+	vec3d "github.com/flywave/go3d/float64/vec3"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatrixMultiply(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal([]float64{2, 0, 4, 0}, matrixMultiply([]float64{1, 0, 0, 2}, []float64{2, 0, 2, 0}, 2, 2, 2))
+}
+
+func TestMatrixInverse(t *testing.T) {
+	a := assert.New(t)
+
+	for _, x := range [][]float64{
+		{4, 1, 1, 3},
+		{0, 1, 1, 0},
+	} {
+		inv, err := matrixInverse(x, 2)
+		a.Nil(err)
+		id := matrixMultiply(x, inv, 2, 2, 2)
+		for i, v := range []float64{1, 0, 0, 1} {
+			a.InDelta(v, id[i], 1e-12)
+		}
+	}
+
+	_, err := matrixInverse([]float64{1, 2, 2, 4}, 2)
+	var cerr *ConditionError
+	a.True(errors.As(err, &cerr))
+	a.Equal(2, cerr.Size)
+
+	x, err := matrixSolveVec([]float64{2, 1, 1, 3}, []float64{3, 5}, 2)
+	a.Nil(err)
+	a.InDelta(0.8, x[0], 1e-12)
+	a.InDelta(1.4, x[1], 1e-12)
+
+	for _, m := range [][]float64{{2, 1, 1, 3}, {0, 1, 1, 0}} {
+		solve, err := matrixFactorize(m, 2)
+		a.Nil(err)
+		x, err = solve([]float64{1, 1})
+		a.Nil(err)
+		a.InDelta(1, m[0]*x[0]+m[1]*x[1], 1e-12)
+	}
+	_, err = matrixSolveVec([]float64{1, 2, 2, 4}, []float64{1, 1}, 2)
+	a.True(errors.As(err, &cerr))
+}
+
+func TestTrainSingular(t *testing.T) {
+	a := assert.New(t)
+
+	pos := krigingPoints()
+	pos = append(pos, vec3d.T{pos[3][0], pos[3][1], pos[3][2] + 1})
+
+	_, err := New(pos).Train(Spherical, 0, 100)
+	var cerr *ConditionError
+	a.True(errors.As(err, &cerr))
+	a.Contains(err.Error(), "linear system")
+}
@@ -0,0 +1,64 @@
+package kriging
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+type normalScore struct {
+	values []float64
+	scores []float64
+}
+
+func newNormalScore(values []float64) (*normalScore, error) {
+	n := len(values)
+	if n == 0 {
+		return nil, errors.New("no values to transform")
+	}
+	sorted := make([]float64, n)
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	ns := &normalScore{}
+	for i := 0; i < n; {
+		j := i
+		for j+1 < n && sorted[j+1] == sorted[i] {
+			j++
+		}
+		p := (float64(i+j)/2 + 0.5) / float64(n)
+		ns.values = append(ns.values, sorted[i])
+		ns.scores = append(ns.scores, distuv.UnitNormal.Quantile(p))
+		i = j + 1
+	}
+	return ns, nil
+}
+
+func (ns *normalScore) Forward(v float64) float64 {
+	return interpolateTable(ns.values, ns.scores, v)
+}
+
+func (ns *normalScore) Backward(y float64) float64 {
+	return interpolateTable(ns.scores, ns.values, y)
+}
+
+func interpolateTable(from, to []float64, v float64) float64 {
+	n := len(from)
+	if n == 1 || v <= from[0] {
+		return to[0]
+	}
+	if v >= from[n-1] {
+		return to[n-1]
+	}
+	i := sort.SearchFloat64s(from, v)
+	if from[i] == v {
+		return to[i]
+	}
+	t := (v - from[i-1]) / (from[i] - from[i-1])
+	if math.IsNaN(t) {
+		return to[i]
+	}
+	return Lerp(to[i-1], to[i], t)
+}
@@ -0,0 +1,279 @@
+package kriging
+
+import (
+	"math"
+	"sort"
+
+	vec3d "github.com/flywave/go3d/float64/vec3"
+)
+
+type OutlierMethod string
+
+const (
+	StatisticalOutlier     OutlierMethod = "statistical"
+	MADOutlier             OutlierMethod = "mad"
+	CrossValidationOutlier OutlierMethod = "cv"
+)
+
+const (
+	default_outlier_neighbours     = 8
+	default_outlier_cv_neighbours  = 16
+	default_statistical_threshold  = 2
+	default_mad_threshold          = 3.5
+	default_cv_threshold           = 3
+	default_mad_scale              = 1.4826
+	default_outlier_variogram_size = 100
+)
+
+type OutlierFilter struct {
+	Method     OutlierMethod
+	Neighbours int
+	Threshold  float64
+	ZScale     float64
+}
+
+type Outlier struct {
+	Index    int
+	Position vec3d.T
+	Method   OutlierMethod
+	Score    float64
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	s := append([]float64{}, values...)
+	sort.Float64s(s)
+	if len(s)%2 == 1 {
+		return s[len(s)/2]
+	}
+	return (s[len(s)/2-1] + s[len(s)/2]) / 2
+}
+
+func robustScore(v, centre, mad float64) float64 {
+	d := math.Abs(v - centre)
+	if mad == 0 {
+		if d == 0 {
+			return 0
+		}
+		return math.Inf(1)
+	}
+	return d / (default_mad_scale * mad)
+}
+
+func outlierNeighbours(idx *pointIndex, pos []vec3d.T, i, k int) []neighbour {
+	found := idx.Nearest(pos[i][0], pos[i][1], k+1, 0)
+	ret := found[:0]
+	for _, n := range found {
+		if n.index != i {
+			ret = append(ret, n)
+		}
+	}
+	if len(ret) > k {
+		ret = ret[:k]
+	}
+	return ret
+}
+
+func spatialNeighbours(idx *pointIndex, pos []vec3d.T, i, k int, zscale float64) []neighbour {
+	found := outlierNeighbours(idx, pos, i, k)
+	if zscale == 0 {
+		return found
+	}
+	dist := func(j int) float64 {
+		return math.Sqrt(math.Pow(pos[j][0]-pos[i][0], 2) + math.Pow(pos[j][1]-pos[i][1], 2) + math.Pow((pos[j][2]-pos[i][2])*zscale, 2))
+	}
+	radius := 0.0
+	for _, n := range found {
+		radius = math.Max(radius, dist(n.index))
+	}
+	ret := neighbourList{}
+	for _, n := range idx.Within(pos[i][0], pos[i][1], radius) {
+		if n.index != i {
+			ret = append(ret, neighbour{n.index, dist(n.index)})
+		}
+	}
+	sort.Sort(ret)
+	if len(ret) > k {
+		ret = ret[:k]
+	}
+	return ret
+}
+
+func statisticalScores(pos []vec3d.T, k int, zscale float64) []float64 {
+	idx := newPointIndex(pos, suggestCellSize(pos, k))
+	dist := make([]float64, len(pos))
+	for i := range pos {
+		found := spatialNeighbours(idx, pos, i, k, zscale)
+		for _, n := range found {
+			dist[i] += n.dist
+		}
+		if len(found) > 0 {
+			dist[i] /= float64(len(found))
+		}
+	}
+
+	mean, std := 0.0, 0.0
+	for _, d := range dist {
+		mean += d
+	}
+	mean /= float64(len(dist))
+	for _, d := range dist {
+		std += (d - mean) * (d - mean)
+	}
+	std = math.Sqrt(std / float64(len(dist)))
+
+	scores := make([]float64, len(pos))
+	for i, d := range dist {
+		if std > 0 {
+			scores[i] = (d - mean) / std
+		}
+	}
+	return scores
+}
+
+func madScores(pos []vec3d.T, k int) []float64 {
+	idx := newPointIndex(pos, suggestCellSize(pos, k))
+	scores := make([]float64, len(pos))
+	values := make([]float64, 0, k)
+	dev := make([]float64, 0, k)
+	for i := range pos {
+		values = values[:0]
+		for _, n := range outlierNeighbours(idx, pos, i, k) {
+			values = append(values, pos[n.index][2])
+		}
+		if len(values) == 0 {
+			continue
+		}
+		m := median(values)
+		dev = dev[:0]
+		for _, v := range values {
+			dev = append(dev, math.Abs(v-m))
+		}
+		scores[i] = robustScore(pos[i][2], m, median(dev))
+	}
+	return scores
+}
+
+func crossValidationResiduals(pos []vec3d.T, variance []float64, model ModelType, k int) ([]float64, error) {
+	global := NewWithVariance(pos, variance)
+	if err := global.fitVariogram(model, default_outlier_variogram_size); err != nil {
+		return nil, err
+	}
+
+	idx := newPointIndex(pos, suggestCellSize(pos, k))
+	residuals := make([]float64, len(pos))
+	for i := range pos {
+		indices := []int{i}
+		for _, n := range outlierNeighbours(idx, pos, i, k) {
+			indices = append(indices, n.index)
+		}
+		sub, err := global.local(indices).solve(0)
+		if err != nil {
+			return nil, err
+		}
+		residuals[i] = sub.M[0] / sub.K[0]
+	}
+	return residuals, nil
+}
+
+func crossValidationScores(pos []vec3d.T, variance []float64, model ModelType, k int) ([]float64, error) {
+	residuals, err := crossValidationResiduals(pos, variance, model, k)
+	if err != nil {
+		return nil, err
+	}
+	m := median(residuals)
+	dev := make([]float64, len(residuals))
+	for i, r := range residuals {
+		dev[i] = math.Abs(r - m)
+	}
+	mad := median(dev)
+
+	scores := make([]float64, len(pos))
+	for i, r := range residuals {
+		scores[i] = robustScore(r, m, mad)
+	}
+	return scores, nil
+}
+
+func (f OutlierFilter) scores(pos []vec3d.T, variance []float64, model ModelType) ([]float64, float64, error) {
+	k, threshold := f.Neighbours, f.Threshold
+	if k <= 0 {
+		k = default_outlier_neighbours
+		if f.Method == CrossValidationOutlier {
+			k = default_outlier_cv_neighbours
+		}
+	}
+	if k >= len(pos) {
+		k = len(pos) - 1
+	}
+
+	switch f.Method {
+	case StatisticalOutlier:
+		if threshold <= 0 {
+			threshold = default_statistical_threshold
+		}
+		zscale := f.ZScale
+		if zscale <= 0 {
+			zscale = 1.0 / default_metres_per_degree
+		}
+		return statisticalScores(pos, k, zscale), threshold, nil
+	case MADOutlier:
+		if threshold <= 0 {
+			threshold = default_mad_threshold
+		}
+		return madScores(pos, k), threshold, nil
+	case CrossValidationOutlier:
+		if threshold <= 0 {
+			threshold = default_cv_threshold
+		}
+		scores, err := crossValidationScores(pos, variance, model, k)
+		return scores, threshold, err
+	}
+	return make([]float64, len(pos)), math.Inf(1), nil
+}
+
+func (p *KrigingInterpolator) removeOutliers(pos []vec3d.T, variance []float64) ([]vec3d.T, []float64, error) {
+	orig := make([]int, len(pos))
+	for i := range orig {
+		orig[i] = i
+		if p.inputIndex != nil {
+			orig[i] = p.inputIndex[i]
+		}
+	}
+
+	for _, f := range p.outliers {
+		if len(pos) < 3 {
+			break
+		}
+		scores, threshold, err := f.scores(pos, variance, p.model)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		n := 0
+		for i := range pos {
+			if scores[i] > threshold {
+				p.report.Outliers = append(p.report.Outliers, Outlier{Index: orig[i], Position: pos[i], Method: f.Method, Score: scores[i]})
+				continue
+			}
+			pos[n], orig[n] = pos[i], orig[i]
+			if variance != nil {
+				variance[n] = variance[i]
+			}
+			if p.inputWeights != nil {
+				p.inputWeights[n] = p.inputWeights[i]
+			}
+			n++
+		}
+		pos, orig = pos[:n], orig[:n]
+		if variance != nil {
+			variance = variance[:n]
+		}
+		if p.inputWeights != nil {
+			p.inputWeights = p.inputWeights[:n]
+		}
+	}
+	return pos, variance, nil
+}
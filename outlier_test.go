@@ -0,0 +1,89 @@
+package kriging
+
+import (
+	"math"
+	"testing"
+
+	vec3d "github.com/flywave/go3d/float64/vec3"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func outlierTestPoints() []vec3d.T {
+	pos := []vec3d.T{}
+	for y := 0; y < 12; y++ {
+		for x := 0; x < 12; x++ {
+			fx, fy := float64(x)*0.01, float64(y)*0.01
+			pos = append(pos, vec3d.T{fx, fy, 100 + 20*math.Sin(fx*20) + 10*fy})
+		}
+	}
+	pos[50][2] += 80
+	return append(pos, vec3d.T{0.5, 0.5, 100})
+}
+
+func TestOutlierScores(t *testing.T) {
+	a := assert.New(t)
+
+	pos := outlierTestPoints()
+	scores := statisticalScores(pos, 8, 0)
+	a.Greater(scores[len(pos)-1], float64(default_statistical_threshold))
+	for _, s := range scores[:len(pos)-1] {
+		a.Less(s, float64(default_statistical_threshold))
+	}
+
+	scores = statisticalScores(pos[:len(pos)-1], 8, 0)
+	a.Less(scores[50], float64(default_statistical_threshold))
+	scores = statisticalScores(pos[:len(pos)-1], 8, 0.001)
+	a.Greater(scores[50], float64(default_statistical_threshold))
+	for i, s := range scores {
+		if i != 50 {
+			a.Less(s, float64(default_statistical_threshold))
+		}
+	}
+
+	scores = madScores(pos[:len(pos)-1], 8)
+	a.Greater(scores[50], float64(default_mad_threshold))
+	for i, s := range scores {
+		if i != 50 {
+			a.Less(s, float64(default_mad_threshold))
+		}
+	}
+
+	residuals, err := crossValidationResiduals(pos[:len(pos)-1], nil, Exponential, 16)
+	a.Nil(err)
+	a.InDelta(80, residuals[50], 20)
+	scores, err = crossValidationScores(pos[:len(pos)-1], nil, Exponential, 16)
+	a.Nil(err)
+	a.Greater(scores[50], float64(default_cv_threshold))
+
+	a.Equal(0.0, robustScore(5, 5, 0))
+	a.True(math.IsInf(robustScore(6, 5, 0), 1))
+	a.Equal(2.5, median([]float64{4, 1, 2, 3}))
+}
+
+func TestRemoveOutliers(t *testing.T) {
+	a := assert.New(t)
+
+	pos := outlierTestPoints()
+	variance := make([]float64, len(pos))
+	p := NewKrigingInterpolator(Options{Outliers: []OutlierFilter{{Method: StatisticalOutlier}, {Method: MADOutlier}}})
+	p.inputWeights = make([]float64, len(pos))
+	for i := range p.inputWeights {
+		p.inputWeights[i] = float64(i)
+	}
+
+	spike := pos[50]
+	out, variance, err := p.removeOutliers(pos, variance)
+	a.Nil(err)
+	a.Len(out, len(pos)-2)
+	a.Len(variance, len(out))
+	a.Len(p.inputWeights, len(out))
+	a.Equal(51.0, p.inputWeights[50])
+
+	report := p.Report()
+	a.Len(report.Outliers, 2)
+	a.Equal(Outlier{Index: 144, Position: vec3d.T{0.5, 0.5, 100}, Method: StatisticalOutlier, Score: report.Outliers[0].Score}, report.Outliers[0])
+	a.Equal(50, report.Outliers[1].Index)
+	a.Equal(spike, report.Outliers[1].Position)
+	a.Equal(MADOutlier, report.Outliers[1].Method)
+}
@@ -0,0 +1,43 @@
+package kriging
+
+import (
+	vec2d "github.com/flywave/go3d/float64/vec2"
+)
+
+func polygonArea(poly []vec2d.T) float64 {
+	area := 0.0
+	for i := range poly {
+		j := (i + 1) % len(poly)
+		area += poly[i][0]*poly[j][1] - poly[j][0]*poly[i][1]
+	}
+	return area / 2
+}
+
+func clipBisector(poly []vec2d.T, a, b vec2d.T) []vec2d.T {
+	n := vec2d.T{b[0] - a[0], b[1] - a[1]}
+	c := (n[0]*(a[0]+b[0]) + n[1]*(a[1]+b[1])) / 2
+	return clipHalfPlane(poly, n, c)
+}
+
+func clipHalfPlane(poly []vec2d.T, n vec2d.T, c float64) []vec2d.T {
+	if len(poly) == 0 {
+		return nil
+	}
+	side := func(p vec2d.T) float64 {
+		return n[0]*p[0] + n[1]*p[1] - c
+	}
+
+	ret := make([]vec2d.T, 0, len(poly)+1)
+	for i := range poly {
+		cur, next := poly[i], poly[(i+1)%len(poly)]
+		sc, sn := side(cur), side(next)
+		if sc <= 0 {
+			ret = append(ret, cur)
+		}
+		if (sc < 0 && sn > 0) || (sc > 0 && sn < 0) {
+			t := sc / (sc - sn)
+			ret = append(ret, vec2d.T{cur[0] + t*(next[0]-cur[0]), cur[1] + t*(next[1]-cur[1])})
+		}
+	}
+	return ret
+}
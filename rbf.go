@@ -0,0 +1,112 @@
+package kriging
+
+import (
+	"errors"
+	"math"
+
+	vec3d "github.com/flywave/go3d/float64/vec3"
+)
+
+type RBFKernel string
+
+const (
+	ThinPlateSpline     RBFKernel = "thinplate"
+	Multiquadric        RBFKernel = "multiquadric"
+	InverseMultiquadric RBFKernel = "inversemultiquadric"
+	GaussianKernel      RBFKernel = "gaussian"
+)
+
+type RBFOptions struct {
+	Kernel    RBFKernel
+	Epsilon   float64
+	Smoothing float64
+}
+
+type RBF struct {
+	pos    []vec3d.T
+	kernel RBFKernel
+	eps    float64
+	centre [2]float64
+	scale  float64
+	w      []float64
+	poly   [3]float64
+}
+
+func NewRBF(pos []vec3d.T, opts RBFOptions) (*RBF, error) {
+	n := len(pos)
+	if n < 3 {
+		return nil, errors.New("not enough points")
+	}
+	if opts.Kernel == "" {
+		opts.Kernel = ThinPlateSpline
+	}
+
+	min, max, _ := minMaxVec3(pos)
+	rbf := &RBF{
+		kernel: opts.Kernel,
+		centre: [2]float64{(min[0] + max[0]) / 2, (min[1] + max[1]) / 2},
+		scale:  math.Max(max[0]-min[0], max[1]-min[1]),
+		pos:    make([]vec3d.T, n),
+	}
+	if rbf.scale <= 0 {
+		return nil, errors.New("points are coincident")
+	}
+	for i := range pos {
+		rbf.pos[i] = vec3d.T{(pos[i][0] - rbf.centre[0]) / rbf.scale, (pos[i][1] - rbf.centre[1]) / rbf.scale, pos[i][2]}
+	}
+	rbf.eps = opts.Epsilon
+	if rbf.eps <= 0 {
+		rbf.eps = suggestCellSize(pos, 1)
+	}
+	rbf.eps /= rbf.scale
+
+	m := n + 3
+	A := make([]float64, m*m)
+	b := make([]float64, m)
+	for i := 0; i < n; i++ {
+		for j := 0; j < i; j++ {
+			A[i*m+j] = rbf.basis(math.Hypot(rbf.pos[i][0]-rbf.pos[j][0], rbf.pos[i][1]-rbf.pos[j][1]))
+			A[j*m+i] = A[i*m+j]
+		}
+		A[i*m+i] = rbf.basis(0) + opts.Smoothing
+		for k, v := range []float64{1, rbf.pos[i][0], rbf.pos[i][1]} {
+			A[i*m+n+k] = v
+			A[(n+k)*m+i] = v
+		}
+		b[i] = rbf.pos[i][2]
+	}
+
+	x, err := matrixSolveVec(A, b, m)
+	if err != nil {
+		return nil, err
+	}
+	rbf.w = x[:n]
+	copy(rbf.poly[:], x[n:])
+	return rbf, nil
+}
+
+func (rbf *RBF) basis(r float64) float64 {
+	switch rbf.kernel {
+	case Multiquadric:
+		return math.Sqrt(r*r + rbf.eps*rbf.eps)
+	case InverseMultiquadric:
+		return 1 / math.Sqrt(r*r+rbf.eps*rbf.eps)
+	case GaussianKernel:
+		return math.Exp(-(r * r) / (rbf.eps * rbf.eps))
+	default:
+		if r == 0 {
+			return 0
+		}
+		return r * r * math.Log(r)
+	}
+}
+
+func (rbf *RBF) Predict(x, y float64) float64 {
+	x = (x - rbf.centre[0]) / rbf.scale
+	y = (y - rbf.centre[1]) / rbf.scale
+	ret := rbf.poly[0] + rbf.poly[1]*x + rbf.poly[2]*y
+	for i := range rbf.pos {
+		ret += rbf.w[i] * rbf.basis(math.Hypot(x-rbf.pos[i][0], y-rbf.pos[i][1]))
+	}
+	return ret
+}
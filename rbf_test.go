@@ -0,0 +1,47 @@
+package kriging
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRBF(t *testing.T) {
+	a := assert.New(t)
+
+	pos := krigingPoints()
+
+	for _, kernel := range []RBFKernel{ThinPlateSpline, Multiquadric, InverseMultiquadric, GaussianKernel} {
+		rbf, err := NewRBF(pos, RBFOptions{Kernel: kernel})
+		a.Nil(err, kernel)
+		if err != nil {
+			continue
+		}
+		for _, i := range []int{0, 20, 45} {
+			a.InDelta(pos[i][2], rbf.Predict(pos[i][0], pos[i][1]), 1e-6, kernel)
+		}
+		a.InDelta(10+3.5+math.Cos(35.0/20), rbf.Predict(35, 35), 0.1, kernel)
+	}
+
+	plane := krigingPoints()
+	for i := range plane {
+		plane[i][2] = 3 + 0.5*plane[i][0] - 0.25*plane[i][1]
+	}
+	tps, err := NewRBF(plane, RBFOptions{})
+	a.Nil(err)
+	a.InDelta(3+0.5*100-0.25*5, tps.Predict(100, 5), 1e-6)
+
+	noisy := krigingPoints()
+	noisy[27][2] += 20
+	exact, err := NewRBF(noisy, RBFOptions{})
+	a.Nil(err)
+	smooth, err := NewRBF(noisy, RBFOptions{Smoothing: 0.1})
+	a.Nil(err)
+	x, y := noisy[27][0], noisy[27][1]
+	a.InDelta(noisy[27][2], exact.Predict(x, y), 1e-6)
+	a.True(smooth.Predict(x, y) < noisy[27][2]-5)
+
+	_, err = NewRBF(pos[:2], RBFOptions{})
+	a.NotNil(err)
+}
@@ -0,0 +1,19 @@
+package kriging
+
+type SkippedFeature struct {
+	Index  int
+	Reason string
+}
+
+type Report struct {
+	Features   int
+	Points     int
+	Filtered   int
+	Skipped    []SkippedFeature
+	Outliers   []Outlier
+	Duplicates []DuplicateGroup
+}
+
+func (r *Report) skip(index int, reason string) {
+	r.Skipped = append(r.Skipped, SkippedFeature{Index: index, Reason: reason})
+}
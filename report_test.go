@@ -0,0 +1,49 @@
+package kriging
+
+import (
+	"testing"
+
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/general"
+
+	vec3d "github.com/flywave/go3d/float64/vec3"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValueProperty(t *testing.T) {
+	a := assert.New(t)
+
+	fc := geom.NewFeatureCollection()
+	for _, v := range []interface{}{12.0, "7.5", nil, "n/a", 3} {
+		fea := geom.NewFeature(general.NewPoint([]float64{1, 2}))
+		if v != nil {
+			fea.Properties["pm25"] = v
+		}
+		fc.AddFeature(fea)
+	}
+	fc.AddFeature(geom.NewFeature(general.NewPoint3([]float64{5, 6, 100})))
+
+	p := NewKrigingInterpolator(Options{Input: fc})
+	pos, _ := p.extractPosion()
+	a.Len(pos, 6)
+	a.Equal(vec3d.T{1, 2, 0}, pos[0])
+	a.Equal(vec3d.T{5, 6, 100}, pos[5])
+	a.Empty(p.Report().Skipped)
+
+	prop := "pm25"
+	p = NewKrigingInterpolator(Options{Input: fc, ValueProperty: &prop})
+	pos, _ = p.extractPosion()
+	a.Equal([]vec3d.T{{1, 2, 12}, {1, 2, 7.5}, {1, 2, 3}}, pos)
+
+	report := p.Report()
+	a.Equal(6, report.Features)
+	a.Equal(3, report.Points)
+	a.Equal([]SkippedFeature{{2, "missing value"}, {3, "non-numeric value"}, {5, "missing value"}}, report.Skipped)
+
+	p.inputPos = pos
+	a.Nil(p.convertHeight())
+	p.heightOffset = 10
+	a.NotNil(p.convertHeight())
+	a.Equal([]vec3d.T{{1, 2, 12}, {1, 2, 7.5}, {1, 2, 3}}, p.inputPos)
+}
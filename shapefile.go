@@ -0,0 +1,284 @@
+package kriging
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/general"
+)
+
+const (
+	default_shp_file_code   = 9994
+	default_shp_header_size = 100
+	default_dbf_terminator  = 0x0d
+	default_dbf_deleted     = '*'
+)
+
+func pointGeometry(c []float64, hasZ bool) geom.Geometry {
+	if hasZ {
+		return general.NewPoint3(c[:3])
+	}
+	return general.NewPoint(c[:2])
+}
+
+func multiPointGeometry(c [][]float64, hasZ bool) geom.Geometry {
+	if hasZ {
+		return general.NewMultiPoint3(c)
+	}
+	return general.NewMultiPoint(c)
+}
+
+func lineGeometry(parts [][][]float64, hasZ bool) geom.Geometry {
+	switch {
+	case len(parts) == 1 && hasZ:
+		return general.NewLineString3(parts[0])
+	case len(parts) == 1:
+		return general.NewLineString(parts[0])
+	case hasZ:
+		return general.NewMultiLineString3(parts)
+	}
+	return general.NewMultiLineString(parts)
+}
+
+func polygonGeometry(rings [][][]float64, hasZ bool) geom.Geometry {
+	if hasZ {
+		return general.NewPolygon3(rings)
+	}
+	return general.NewPolygon(rings)
+}
+
+func shpGeometry(rec []byte) (geom.Geometry, error) {
+	le := binary.LittleEndian
+	if len(rec) < 4 {
+		return nil, errors.New("truncated shapefile record")
+	}
+	kind := int(le.Uint32(rec))
+	hasZ := kind >= 10 && kind < 20
+	float := func(off int) float64 {
+		return math.Float64frombits(le.Uint64(rec[off:]))
+	}
+	need := func(n int) error {
+		if len(rec) < n {
+			return errors.New("truncated shapefile record")
+		}
+		return nil
+	}
+
+	switch kind {
+	case 0:
+		return nil, nil
+	case 1, 11, 21:
+		if err := need(20); err != nil {
+			return nil, err
+		}
+		c := []float64{float(4), float(12), 0}
+		if hasZ {
+			if err := need(28); err != nil {
+				return nil, err
+			}
+			c[2] = float(20)
+		}
+		return pointGeometry(c, hasZ), nil
+	case 8, 18, 28:
+		if err := need(40); err != nil {
+			return nil, err
+		}
+		n := int(le.Uint32(rec[36:]))
+		zoff := 40 + 16*n + 16
+		if err := need(40 + 16*n); err != nil {
+			return nil, err
+		}
+		if hasZ {
+			if err := need(zoff + 8*n); err != nil {
+				return nil, err
+			}
+		}
+		pts := make([][]float64, n)
+		for i := range pts {
+			pts[i] = []float64{float(40 + 16*i), float(48 + 16*i)}
+			if hasZ {
+				pts[i] = append(pts[i], float(zoff+8*i))
+			}
+		}
+		return multiPointGeometry(pts, hasZ), nil
+	case 3, 13, 23, 5, 15, 25:
+		if err := need(44); err != nil {
+			return nil, err
+		}
+		np, n := int(le.Uint32(rec[36:])), int(le.Uint32(rec[40:]))
+		poff := 44 + 4*np
+		zoff := poff + 16*n + 16
+		if err := need(poff + 16*n); err != nil {
+			return nil, err
+		}
+		if hasZ {
+			if err := need(zoff + 8*n); err != nil {
+				return nil, err
+			}
+		}
+		parts := make([][][]float64, np)
+		for p := range parts {
+			start, end := int(le.Uint32(rec[44+4*p:])), n
+			if p+1 < np {
+				end = int(le.Uint32(rec[48+4*p:]))
+			}
+			if start > end || end > n {
+				return nil, errors.New("invalid shapefile part index")
+			}
+			for i := start; i < end; i++ {
+				c := []float64{float(poff + 16*i), float(poff + 8 + 16*i)}
+				if hasZ {
+					c = append(c, float(zoff+8*i))
+				}
+				parts[p] = append(parts[p], c)
+			}
+		}
+		if kind%10 == 5 {
+			return polygonGeometry(parts, hasZ), nil
+		}
+		return lineGeometry(parts, hasZ), nil
+	}
+	return nil, fmt.Errorf("unsupported shapefile shape type %d", kind)
+}
+
+type dbfField struct {
+	name   string
+	kind   byte
+	length int
+}
+
+type dbfReader struct {
+	r      *bufio.Reader
+	fields []dbfField
+	size   int
+}
+
+func newDBFReader(r io.Reader) (*dbfReader, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, 32)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("dbf header: %w", err)
+	}
+	le := binary.LittleEndian
+	d := &dbfReader{r: br, size: int(le.Uint16(header[10:]))}
+	headerSize := int(le.Uint16(header[8:]))
+
+	read := 32
+	for read+32 < headerSize {
+		desc := make([]byte, 32)
+		if _, err := io.ReadFull(br, desc); err != nil {
+			return nil, fmt.Errorf("dbf field: %w", err)
+		}
+		read += 32
+		if desc[0] == default_dbf_terminator {
+			break
+		}
+		name := string(desc[:11])
+		if i := strings.IndexByte(name, 0); i >= 0 {
+			name = name[:i]
+		}
+		d.fields = append(d.fields, dbfField{name: strings.TrimSpace(name), kind: desc[11], length: int(desc[16])})
+	}
+	if _, err := br.Discard(headerSize - read); err != nil {
+		return nil, fmt.Errorf("dbf header: %w", err)
+	}
+	return d, nil
+}
+
+func (d *dbfReader) Read() (map[string]interface{}, bool, error) {
+	rec := make([]byte, d.size)
+	if _, err := io.ReadFull(d.r, rec); err != nil {
+		return nil, false, err
+	}
+	props := make(map[string]interface{}, len(d.fields))
+	off := 1
+	for _, f := range d.fields {
+		if off+f.length > len(rec) {
+			break
+		}
+		s := strings.TrimSpace(string(rec[off : off+f.length]))
+		off += f.length
+		switch f.kind {
+		case 'N', 'F':
+			if v, err := strconv.ParseFloat(s, 64); err == nil {
+				props[f.name] = v
+			}
+		default:
+			props[f.name] = s
+		}
+	}
+	return props, rec[0] != default_dbf_deleted, nil
+}
+
+func ReadShapefile(path string) (*geom.FeatureCollection, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+
+	header := make([]byte, default_shp_header_size)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, "", fmt.Errorf("shapefile header: %w", err)
+	}
+	if binary.BigEndian.Uint32(header) != default_shp_file_code {
+		return nil, "", errors.New("not a shapefile")
+	}
+
+	base := strings.TrimSuffix(path, ".shp")
+	var dbf *dbfReader
+	if df, err := os.Open(base + ".dbf"); err == nil {
+		defer df.Close()
+		if dbf, err = newDBFReader(df); err != nil {
+			return nil, "", err
+		}
+	}
+	srs := ""
+	if prj, err := os.ReadFile(base + ".prj"); err == nil {
+		srs = strings.TrimSpace(string(prj))
+	}
+
+	fc := geom.NewFeatureCollection()
+	rh := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, rh); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, "", fmt.Errorf("shapefile record: %w", err)
+		}
+		rec := make([]byte, 2*int(binary.BigEndian.Uint32(rh[4:])))
+		if _, err := io.ReadFull(r, rec); err != nil {
+			return nil, "", fmt.Errorf("shapefile record: %w", err)
+		}
+		g, err := shpGeometry(rec)
+		if err != nil {
+			return nil, "", err
+		}
+
+		props, keep := map[string]interface{}{}, true
+		if dbf != nil {
+			if props, keep, err = dbf.Read(); err != nil {
+				return nil, "", fmt.Errorf("dbf record: %w", err)
+			}
+		}
+		if g == nil || !keep {
+			continue
+		}
+		fea := geom.NewFeature(g)
+		for k, v := range props {
+			fea.Properties[k] = v
+		}
+		fc.AddFeature(fea)
+	}
+	return fc, srs, nil
+}
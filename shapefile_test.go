@@ -0,0 +1,117 @@
+package kriging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	vec3d "github.com/flywave/go3d/float64/vec3"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestShapefile(t *testing.T, dir string) string {
+	shp := &bytes.Buffer{}
+	header := make([]byte, default_shp_header_size)
+	binary.BigEndian.PutUint32(header, default_shp_file_code)
+	binary.LittleEndian.PutUint32(header[28:], 1000)
+	binary.LittleEndian.PutUint32(header[32:], 11)
+	shp.Write(header)
+
+	le := binary.LittleEndian
+	point := func(n int, x, y, z float64) {
+		rec := make([]byte, 36)
+		le.PutUint32(rec, 11)
+		for i, v := range []float64{x, y, z, 0} {
+			le.PutUint64(rec[4+8*i:], math.Float64bits(v))
+		}
+		rh := make([]byte, 8)
+		binary.BigEndian.PutUint32(rh, uint32(n))
+		binary.BigEndian.PutUint32(rh[4:], uint32(len(rec)/2))
+		shp.Write(rh)
+		shp.Write(rec)
+	}
+	point(1, 1, 2, 3)
+	point(2, 4, 5, 6)
+	point(3, 7, 8, 9)
+
+	dbf := &bytes.Buffer{}
+	dh := make([]byte, 32)
+	le.PutUint32(dh[4:], 3)
+	le.PutUint16(dh[8:], 32+32+1)
+	le.PutUint16(dh[10:], 1+6)
+	dbf.Write(dh)
+	field := make([]byte, 32)
+	copy(field, "PM25")
+	field[11], field[16], field[17] = 'N', 6, 1
+	dbf.Write(field)
+	dbf.WriteByte(default_dbf_terminator)
+	dbf.WriteString("    1.5")
+	dbf.WriteString("*   2.5")
+	dbf.WriteString("    n/a")
+
+	base := filepath.Join(dir, "points")
+	for ext, data := range map[string][]byte{
+		".shp": shp.Bytes(),
+		".dbf": dbf.Bytes(),
+		".prj": []byte(`PROJCS["WGS_1984_UTM_Zone_33N",GEOGCS["GCS_WGS_1984"]]`),
+	} {
+		if err := os.WriteFile(base+ext, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return base + ".shp"
+}
+
+func TestReadShapefile(t *testing.T) {
+	a := assert.New(t)
+
+	fc, srs, err := ReadShapefile(writeTestShapefile(t, t.TempDir()))
+	a.Nil(err)
+	a.Equal(32633, wktEPSG(srs))
+	a.Len(fc.Features, 2)
+	a.Equal(1.5, fc.Features[0].Properties["PM25"])
+
+	p := NewKrigingInterpolator(Options{Input: fc})
+	pos, _ := p.extractPosion()
+	a.Equal([]vec3d.T{{1, 2, 3}, {7, 8, 9}}, pos)
+
+	prop := "PM25"
+	p = NewKrigingInterpolator(Options{Input: fc, ValueProperty: &prop})
+	pos, _ = p.extractPosion()
+	a.Equal([]vec3d.T{{1, 2, 1.5}}, pos)
+
+	a.Equal(4326, wktEPSG(`GEOGCS["WGS 84",DATUM["WGS_1984"],AUTHORITY["EPSG","4326"]]`))
+	a.Equal(32750, wktEPSG(`PROJCS["WGS 84 / UTM zone 50S",GEOGCS["WGS 84"]]`))
+	a.Equal(3857, wktEPSG(`PROJCS["WGS_1984_Web_Mercator_Auxiliary_Sphere"]`))
+	a.Equal(0, wktEPSG(`LOCAL_CS["unknown"]`))
+}
+
+func TestShapefileCoordinateSystem(t *testing.T) {
+	a := assert.New(t)
+
+	path := writeTestShapefile(t, t.TempDir())
+	prj := strings.TrimSuffix(path, ".shp") + ".prj"
+	a.Nil(os.WriteFile(prj, []byte(`PROJCS["NAD_1983_UTM_Zone_10N",GEOGCS["GCS_North_American_1983",DATUM["D_North_American_1983",`+
+		`SPHEROID["GRS_1980",6378137.0,298.257222101]],PRIMEM["Greenwich",0.0],UNIT["Degree",0.0174532925199433]],`+
+		`PROJECTION["Transverse_Mercator"],PARAMETER["False_Easting",500000.0],PARAMETER["False_Northing",0.0],`+
+		`PARAMETER["Central_Meridian",-123.0],PARAMETER["Scale_Factor",0.9996],PARAMETER["Latitude_Of_Origin",0.0],UNIT["Meter",1.0]]`), 0644))
+
+	p := NewKrigingInterpolator(Options{InputShapefile: &path})
+	a.Nil(p.loadInput())
+	a.Equal("EPSG:26910", p.inputProj.GetSrsCode())
+	a.Equal(26915, wktEPSG(`PROJCS["NAD83 / UTM zone 15N",GEOGCS["NAD83"]]`))
+
+	a.Nil(os.WriteFile(prj, []byte(`LOCAL_CS["site grid",LOCAL_DATUM["unknown",0],UNIT["Meter",1.0]]`), 0644))
+	p = NewKrigingInterpolator(Options{InputShapefile: &path})
+	a.NotNil(p.loadInput())
+
+	srs := "EPSG:3857"
+	p = NewKrigingInterpolator(Options{InputShapefile: &path, InputSrs: &srs})
+	a.Nil(p.loadInput())
+	a.Equal(srs, p.inputProj.GetSrsCode())
+}
@@ -0,0 +1,162 @@
+package kriging
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+
+	vec2d "github.com/flywave/go3d/float64/vec2"
+	vec3d "github.com/flywave/go3d/float64/vec3"
+)
+
+const (
+	default_sim_realisations = 1
+	default_sim_neighbours   = 16
+)
+
+type SimulationOptions struct {
+	Realisations  int
+	Seed          int64
+	MaxNeighbours int
+	SearchRadius  float64 // in input coordinate units, so degrees for KrigingInterpolator
+	Summary       bool
+	Quantiles     []float64
+}
+
+type Simulation struct {
+	opts    SimulationOptions
+	data    []vec3d.T
+	ns      *normalScore
+	kriging *Kriging
+	rng     *rand.Rand
+}
+
+func NewSimulation(pos []vec3d.T, model ModelType, opts SimulationOptions) (*Simulation, error) {
+	if len(pos) == 0 {
+		return nil, errors.New("no conditioning points")
+	}
+	if opts.Realisations <= 0 {
+		opts.Realisations = default_sim_realisations
+	}
+	if opts.MaxNeighbours <= 0 {
+		opts.MaxNeighbours = default_sim_neighbours
+	}
+
+	values := make([]float64, len(pos))
+	for i := range pos {
+		values[i] = pos[i][2]
+	}
+	ns, err := newNormalScore(values)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]vec3d.T, len(pos))
+	for i := range pos {
+		data[i] = vec3d.T{pos[i][0], pos[i][1], ns.Forward(pos[i][2])}
+	}
+
+	kri := New(data)
+	if err := kri.fitVariogram(model, 100); err != nil {
+		return nil, err
+	}
+
+	return &Simulation{
+		opts:    opts,
+		data:    data,
+		ns:      ns,
+		kriging: kri,
+		rng:     rand.New(rand.NewSource(opts.Seed)),
+	}, nil
+}
+
+func (s *Simulation) Realise(nodes []vec2d.T) ([]float64, error) {
+	all := make([]vec3d.T, 0, len(s.data)+len(nodes))
+	all = append(all, s.data...)
+	for i := range nodes {
+		all = append(all, vec3d.T{nodes[i][0], nodes[i][1], 0})
+	}
+	idx := newPointIndex(s.data, suggestCellSize(all, s.opts.MaxNeighbours))
+
+	ret := make([]float64, len(nodes))
+	for _, i := range s.rng.Perm(len(nodes)) {
+		nb := idx.Nearest(nodes[i][0], nodes[i][1], s.opts.MaxNeighbours, s.opts.SearchRadius)
+		mean, variance, err := s.kriging.simpleKrige(idx.pos, nb, nodes[i][0], nodes[i][1], 0)
+		if err != nil {
+			return nil, err
+		}
+		y := mean + math.Sqrt(variance)*s.rng.NormFloat64()
+		idx.Insert(vec3d.T{nodes[i][0], nodes[i][1], y})
+		ret[i] = s.ns.Backward(y)
+	}
+	return ret, nil
+}
+
+func (s *Simulation) Run(nodes []vec2d.T, fn func(r int, values []float64) error) error {
+	for r := 0; r < s.opts.Realisations; r++ {
+		values, err := s.Realise(nodes)
+		if err != nil {
+			return err
+		}
+		if err := fn(r, values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Simulation) Summarise(nodes []vec2d.T) ([]float64, [][]float64, error) {
+	n := s.opts.Realisations
+	samples := make([][]float64, len(nodes))
+	for i := range samples {
+		samples[i] = make([]float64, 0, n)
+	}
+
+	err := s.Run(nodes, func(r int, values []float64) error {
+		for i := range values {
+			samples[i] = append(samples[i], values[i])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	etype := make([]float64, len(nodes))
+	quantiles := make([][]float64, len(s.opts.Quantiles))
+	for q := range quantiles {
+		quantiles[q] = make([]float64, len(nodes))
+	}
+	for i := range samples {
+		sum := 0.0
+		for _, v := range samples[i] {
+			sum += v
+		}
+		etype[i] = sum / float64(n)
+
+		sort.Float64s(samples[i])
+		for q, p := range s.opts.Quantiles {
+			quantiles[q][i] = quantile(samples[i], p)
+		}
+	}
+	return etype, quantiles, nil
+}
+
+func quantile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+	if p <= 0 {
+		return sorted[0]
+	}
+	if p >= 1 {
+		return sorted[len(sorted)-1]
+	}
+	f := p * float64(len(sorted)-1)
+	i := int(f)
+	if i+1 >= len(sorted) {
+		return sorted[i]
+	}
+	return Lerp(sorted[i], sorted[i+1], f-float64(i))
+}
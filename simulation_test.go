@@ -0,0 +1,72 @@
+package kriging
+
+import (
+	"math"
+	"testing"
+
+	vec2d "github.com/flywave/go3d/float64/vec2"
+	vec3d "github.com/flywave/go3d/float64/vec3"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func simulationPoints() []vec3d.T {
+	pos := []vec3d.T{}
+	for i := 0; i < 10; i++ {
+		for j := 0; j < 10; j++ {
+			x, y := float64(i)*10, float64(j)*10
+			pos = append(pos, vec3d.T{x, y, 50 + 10*math.Sin(x/30)*math.Cos(y/30)})
+		}
+	}
+	return pos
+}
+
+func TestNormalScore(t *testing.T) {
+	a := assert.New(t)
+
+	ns, err := newNormalScore([]float64{3, 1, 2, 2, 5})
+	a.Nil(err)
+
+	for _, v := range []float64{1, 2, 3, 5} {
+		a.InDelta(v, ns.Backward(ns.Forward(v)), 1e-9)
+	}
+	a.True(ns.Forward(1) < ns.Forward(2))
+	a.Equal(1.0, ns.Backward(-10))
+	a.Equal(5.0, ns.Backward(10))
+}
+
+func TestSimulationReproducible(t *testing.T) {
+	a := assert.New(t)
+
+	nodes := []vec2d.T{{5, 5}, {15, 25}, {45, 45}, {85, 15}, {20, 0}}
+	opts := SimulationOptions{Realisations: 2, Seed: 42}
+
+	s1, err := NewSimulation(simulationPoints(), Spherical, opts)
+	a.Nil(err)
+	s2, _ := NewSimulation(simulationPoints(), Spherical, opts)
+
+	r1, err := s1.Realise(nodes)
+	a.Nil(err)
+	r2, _ := s2.Realise(nodes)
+	a.Equal(r1, r2)
+
+	for _, v := range r1 {
+		a.True(v >= 40 && v <= 60)
+	}
+	a.InDelta(50+10*math.Sin(20.0/30), r1[4], 1e-9)
+}
+
+func TestSimulationSummary(t *testing.T) {
+	a := assert.New(t)
+
+	nodes := []vec2d.T{{5, 5}, {45, 45}}
+	s, _ := NewSimulation(simulationPoints(), Spherical, SimulationOptions{Realisations: 20, Seed: 1, Quantiles: []float64{0.1, 0.9}})
+
+	etype, quantiles, err := s.Summarise(nodes)
+	a.Nil(err)
+	a.Len(etype, 2)
+	a.Len(quantiles, 2)
+	for i := range nodes {
+		a.True(quantiles[0][i] <= etype[i] && etype[i] <= quantiles[1][i])
+	}
+}
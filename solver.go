@@ -0,0 +1,236 @@
+package kriging
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+
+	vec3d "github.com/flywave/go3d/float64/vec3"
+)
+
+type SolverType string
+
+const (
+	DenseSolver     SolverType = "dense"
+	FixedRankSolver SolverType = "fixedrank"
+	TaperedSolver   SolverType = "tapered"
+)
+
+const (
+	default_solver_rank       = 256
+	default_solver_tolerance  = 1e-8
+	default_solver_iterations = 1000
+	default_variogram_points  = 3000
+	default_taper_neighbours  = 50
+	default_solver_nugget     = 1e-3
+)
+
+type SolverOptions struct {
+	Type          SolverType
+	Rank          int
+	TaperRange    float64 // in the units of the input coordinates, degrees for KrigingInterpolator
+	Tolerance     float64
+	MaxIterations int
+	Seed          int64
+	Nugget        float64
+}
+
+type approxSystem struct {
+	mean  float64
+	w     []float64
+	index *pointIndex
+	taper float64
+	knots []vec3d.T
+}
+
+func (kri *Kriging) SetSolver(opts SolverOptions) {
+	if opts.Rank <= 0 {
+		opts.Rank = default_solver_rank
+	}
+	if opts.Tolerance <= 0 {
+		opts.Tolerance = default_solver_tolerance
+	}
+	if opts.MaxIterations <= 0 {
+		opts.MaxIterations = default_solver_iterations
+	}
+	if opts.Nugget <= 0 {
+		opts.Nugget = default_solver_nugget
+	}
+	kri.solver = opts
+}
+
+func (kri *Kriging) variogramSample() ([]vec3d.T, []float64) {
+	n := len(kri.pos)
+	if n <= default_variogram_points {
+		return kri.pos, kri.weights
+	}
+	rng := rand.New(rand.NewSource(kri.solver.Seed))
+	pos := make([]vec3d.T, default_variogram_points)
+	var weights []float64
+	if len(kri.weights) == n {
+		weights = make([]float64, default_variogram_points)
+	}
+	for i, j := range rng.Perm(n)[:default_variogram_points] {
+		pos[i] = kri.pos[j]
+		if weights != nil {
+			weights[i] = kri.weights[j]
+		}
+	}
+	return pos, weights
+}
+
+func (kri *Kriging) nuggetVariance(i int, sigma2 float64) float64 {
+	v := sigma2 + kri.nuggetEffect() + kri.solver.Nugget*kri.continuousCovariance(0)
+	if len(kri.variance) == len(kri.pos) {
+		v += kri.variance[i]
+	}
+	return v
+}
+
+func (kri *Kriging) residuals() (float64, []float64) {
+	mean := NewStatistics(kri.pos, kri.weights).Mean
+	r := make([]float64, len(kri.pos))
+	for i := range kri.pos {
+		r[i] = kri.pos[i][2] - mean
+	}
+	return mean, r
+}
+
+func (kri *Kriging) trainApprox(sigma2 float64) error {
+	if kri.continuousCovariance(0) <= 0 {
+		return errors.New("variogram has no positive sill")
+	}
+	switch kri.solver.Type {
+	case FixedRankSolver:
+		return kri.trainFixedRank(sigma2)
+	case TaperedSolver:
+		return kri.trainTapered(sigma2)
+	}
+	return errors.New("unknown solver " + string(kri.solver.Type))
+}
+
+func (kri *Kriging) trainTapered(sigma2 float64) error {
+	n := len(kri.pos)
+	taper := kri.solver.TaperRange
+	if taper <= 0 {
+		taper = math.Min(kri.rangex, suggestCellSize(kri.pos, default_taper_neighbours)/math.Sqrt(math.Pi))
+	}
+	if taper <= 0 {
+		return errors.New("invalid taper range")
+	}
+	mean, r := kri.residuals()
+
+	idx := newPointIndex(kri.pos, taper)
+	K := newCSRMatrix(n)
+	for i := 0; i < n; i++ {
+		nb := idx.Within(kri.pos[i][0], kri.pos[i][1], taper)
+		cols := make([]int, 0, len(nb))
+		vals := make([]float64, 0, len(nb))
+		for _, b := range nb {
+			v := kri.taperedCovariance(b.dist, taper)
+			if b.index == i {
+				v = kri.continuousCovariance(0) + kri.nuggetVariance(i, sigma2)
+			}
+			cols = append(cols, b.index)
+			vals = append(vals, v)
+		}
+		K.appendRow(cols, vals)
+	}
+
+	w, err := conjugateGradient(K.MulVec, r, K.Diagonal(), kri.solver.Tolerance, kri.solver.MaxIterations)
+	if err != nil {
+		return err
+	}
+	kri.approx = &approxSystem{mean: mean, w: w, index: idx, taper: taper}
+	return nil
+}
+
+func (kri *Kriging) taperedCovariance(h, taper float64) float64 {
+	if h >= taper {
+		return 0
+	}
+	t := h / taper
+	return kri.continuousCovariance(h) * math.Pow(1-t, 4) * (4*t + 1)
+}
+
+func (kri *Kriging) trainFixedRank(sigma2 float64) error {
+	n := len(kri.pos)
+	m := kri.solver.Rank
+	if m > n {
+		m = n
+	}
+	mean, r := kri.residuals()
+
+	rng := rand.New(rand.NewSource(kri.solver.Seed))
+	knots := make([]vec3d.T, m)
+	for i, j := range rng.Perm(n)[:m] {
+		knots[i] = kri.pos[j]
+	}
+
+	c0 := kri.continuousCovariance(0)
+	jitter := c0 * 1e-8
+	Kmm := make([]float64, m*m)
+	for i := 0; i < m; i++ {
+		for j := 0; j < m; j++ {
+			Kmm[i*m+j] = kri.continuousCovariance(math.Hypot(knots[i][0]-knots[j][0], knots[i][1]-knots[j][1]))
+		}
+		Kmm[i*m+i] += jitter
+	}
+
+	solve, err := matrixFactorize(Kmm, m)
+	if err != nil {
+		return err
+	}
+
+	A := make([]float64, m*m)
+	copy(A, Kmm)
+	u := make([]float64, m)
+	k := make([]float64, m)
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			k[j] = kri.continuousCovariance(math.Hypot(kri.pos[i][0]-knots[j][0], kri.pos[i][1]-knots[j][1]))
+		}
+		s, err := solve(k)
+		if err != nil {
+			return err
+		}
+		d := c0 - vecDot(k, s) + kri.nuggetVariance(i, sigma2)
+		if d < jitter {
+			d = jitter
+		}
+		for a := 0; a < m; a++ {
+			u[a] += k[a] * r[i] / d
+			for b := a; b < m; b++ {
+				A[a*m+b] += k[a] * k[b] / d
+			}
+		}
+	}
+	for a := 0; a < m; a++ {
+		for b := 0; b < a; b++ {
+			A[a*m+b] = A[b*m+a]
+		}
+	}
+
+	w, err := matrixSolveVec(A, u, m)
+	if err != nil {
+		return err
+	}
+	kri.approx = &approxSystem{mean: mean, w: w, knots: knots}
+	return nil
+}
+
+func (kri *Kriging) predictApprox(x, y float64) float64 {
+	a := kri.approx
+	ret := a.mean
+	switch kri.solver.Type {
+	case FixedRankSolver:
+		for j := range a.knots {
+			ret += kri.continuousCovariance(math.Hypot(x-a.knots[j][0], y-a.knots[j][1])) * a.w[j]
+		}
+	case TaperedSolver:
+		for _, b := range a.index.Within(x, y, a.taper) {
+			ret += kri.taperedCovariance(b.dist, a.taper) * a.w[b.index]
+		}
+	}
+	return ret
+}
@@ -0,0 +1,44 @@
+package kriging
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApproximateSolvers(t *testing.T) {
+	a := assert.New(t)
+
+	pos := krigingPoints()
+
+	for _, solver := range []SolverType{TaperedSolver, FixedRankSolver} {
+		kri := New(pos)
+		kri.SetSolver(SolverOptions{Type: solver, Rank: len(pos), TaperRange: 40})
+		_, err := kri.Train(Exponential, 0, 100)
+		a.Nil(err, solver)
+		if err != nil {
+			continue
+		}
+
+		for _, i := range []int{0, 20, 45} {
+			a.InDelta(pos[i][2], kri.Predict(pos[i][0], pos[i][1]), 0.05, solver)
+		}
+		v := kri.Predict(35, 35)
+		a.False(math.IsNaN(v), solver)
+		a.InDelta(10+3.5+math.Cos(35.0/20), v, 1, solver)
+	}
+}
+
+func TestConjugateGradient(t *testing.T) {
+	a := assert.New(t)
+
+	K := newCSRMatrix(2)
+	K.appendRow([]int{0, 1}, []float64{4, 1})
+	K.appendRow([]int{0, 1}, []float64{1, 3})
+
+	x, err := conjugateGradient(K.MulVec, []float64{1, 2}, K.Diagonal(), 1e-12, 10)
+	a.Nil(err)
+	a.InDelta(1.0/11, x[0], 1e-9)
+	a.InDelta(7.0/11, x[1], 1e-9)
+}
@@ -0,0 +1,302 @@
+package kriging
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/flywave/go-geo"
+	"github.com/flywave/go-geom"
+
+	vec2d "github.com/flywave/go3d/float64/vec2"
+	vec3d "github.com/flywave/go3d/float64/vec3"
+)
+
+type Sample struct {
+	X        float64
+	Y        float64
+	Value    float64
+	Weight   float64
+	Variance float64
+	Time     time.Time
+}
+
+type PointSource interface {
+	Read() (Sample, error)
+}
+
+type PointSourceFunc func() (Sample, error)
+
+func (f PointSourceFunc) Read() (Sample, error) {
+	return f()
+}
+
+type SliceSource struct {
+	samples []Sample
+	next    int
+}
+
+func NewSliceSource(samples []Sample) *SliceSource {
+	return &SliceSource{samples: samples}
+}
+
+func NewPositionSource(pos []vec3d.T) *SliceSource {
+	samples := make([]Sample, len(pos))
+	for i, p := range pos {
+		samples[i] = Sample{X: p[0], Y: p[1], Value: p[2]}
+	}
+	return NewSliceSource(samples)
+}
+
+func (s *SliceSource) Read() (Sample, error) {
+	if s.next >= len(s.samples) {
+		return Sample{}, io.EOF
+	}
+	s.next++
+	return s.samples[s.next-1], nil
+}
+
+type FeatureSourceOptions struct {
+	Srs              *string
+	ValueProperty    *string
+	VarianceProperty *string
+	TimeProperty     *string
+}
+
+type FeatureSource struct {
+	features         []*geom.Feature
+	proj             geo.Proj
+	valueProperty    *string
+	varianceProperty *string
+	timeProperty     *string
+	breakline        func(fea *geom.Feature) []Breakline
+	lines            []Breakline
+	pending          []Sample
+	next             int
+	report           Report
+}
+
+func NewFeatureSource(fc *geom.FeatureCollection, opts FeatureSourceOptions) *FeatureSource {
+	s := &FeatureSource{
+		features:         fc.Features,
+		valueProperty:    opts.ValueProperty,
+		varianceProperty: opts.VarianceProperty,
+		timeProperty:     opts.TimeProperty,
+		report:           Report{Features: len(fc.Features)},
+	}
+	if opts.Srs != nil {
+		s.proj = geo.NewProj(*opts.Srs)
+	}
+	return s
+}
+
+func (s *FeatureSource) Read() (Sample, error) {
+	for len(s.pending) == 0 {
+		if s.next >= len(s.features) {
+			return Sample{}, io.EOF
+		}
+		s.push(s.next, s.features[s.next])
+		s.next++
+	}
+	return s.pop(), nil
+}
+
+func (s *FeatureSource) push(index int, fea *geom.Feature) {
+	var ts time.Time
+	if s.timeProperty != nil {
+		t, ok := parseTime(fea.Properties[*s.timeProperty])
+		if !ok {
+			s.report.skip(index, "missing timestamp")
+			return
+		}
+		ts = secondsTime(t)
+	}
+
+	var variance float64
+	var pos []vec3d.T
+	if lines := s.breaklines(fea); lines != nil {
+		v, ok := featureValue(&s.report, s.valueProperty, index, fea)
+		if !ok {
+			return
+		}
+		for _, l := range lines {
+			if s.valueProperty != nil {
+				for j := range l.Vertices {
+					l.Vertices[j][2] = v
+				}
+			}
+			pos = append(pos, l.Vertices...)
+		}
+		s.lines = append(s.lines, lines...)
+	} else if v, ok := featureValue(&s.report, s.valueProperty, index, fea); ok {
+		if variance, ok = featureVariance(&s.report, s.varianceProperty, index, fea); !ok {
+			return
+		}
+		pos = projectGeometry(s.proj, fea.Geometry, nil)
+		if s.valueProperty != nil {
+			for j := range pos {
+				pos[j][2] = v
+			}
+		}
+	}
+	for _, p := range pos {
+		s.pending = append(s.pending, Sample{X: p[0], Y: p[1], Value: p[2], Variance: variance, Time: ts})
+	}
+}
+
+func (s *FeatureSource) pop() Sample {
+	sample := s.pending[0]
+	s.pending = s.pending[1:]
+	s.report.Points++
+	return sample
+}
+
+func (s *FeatureSource) breaklines(fea *geom.Feature) []Breakline {
+	if s.breakline == nil {
+		return nil
+	}
+	return s.breakline(fea)
+}
+
+func (s *FeatureSource) Report() Report {
+	return s.report
+}
+
+func featureNumber(report *Report, property *string, index int, feas *geom.Feature, name string) (float64, bool) {
+	if property == nil {
+		return 0, true
+	}
+	raw, ok := feas.Properties[*property]
+	if !ok || raw == nil {
+		report.skip(index, "missing "+name)
+		return 0, false
+	}
+	v, ok := propertyFloat(raw)
+	if !ok || math.IsNaN(v) || math.IsInf(v, 0) {
+		report.skip(index, "non-numeric "+name)
+		return 0, false
+	}
+	return v, true
+}
+
+func featureValue(report *Report, property *string, index int, feas *geom.Feature) (float64, bool) {
+	return featureNumber(report, property, index, feas, "value")
+}
+
+func featureVariance(report *Report, property *string, index int, feas *geom.Feature) (float64, bool) {
+	v, ok := featureNumber(report, property, index, feas, "variance")
+	if ok && v < 0 {
+		report.skip(index, "negative variance")
+		return 0, false
+	}
+	return v, ok
+}
+
+func geometryCoords(g_ geom.Geometry, ret [][]float64) [][]float64 {
+	switch g := g_.(type) {
+	case interface{ Data() []float64 }:
+		ret = append(ret, g.Data())
+	case interface{ Data() [][]float64 }:
+		ret = append(ret, g.Data()...)
+	case interface{ Data() [][][]float64 }:
+		for _, line := range g.Data() {
+			ret = append(ret, line...)
+		}
+	case interface{ Data() [][][][]float64 }:
+		for _, poly := range g.Data() {
+			for _, line := range poly {
+				ret = append(ret, line...)
+			}
+		}
+	case geom.Collection:
+		for _, sub := range g {
+			ret = geometryCoords(sub, ret)
+		}
+	}
+	return ret
+}
+
+func projectGeometry(proj geo.Proj, g geom.Geometry, ret []vec3d.T) []vec3d.T {
+	coords := geometryCoords(g, nil)
+	n := 0
+	for _, c := range coords {
+		if len(c) >= 2 {
+			coords[n] = c
+			n++
+		}
+	}
+	coords = coords[:n]
+	if len(coords) == 0 {
+		return ret
+	}
+
+	var pos2 []vec2d.T
+	if proj != nil && !proj.Eq(epsg4326) {
+		pos2 = make([]vec2d.T, len(coords))
+		for i, c := range coords {
+			pos2[i] = vec2d.T{c[0], c[1]}
+		}
+		pos2 = proj.TransformTo(epsg4326, pos2)
+	}
+	for i, c := range coords {
+		x, y := c[0], c[1]
+		if pos2 != nil {
+			x, y = pos2[i][0], pos2[i][1]
+		}
+		ret = append(ret, vec3d.T{x, y, pointZ(c)})
+	}
+	return ret
+}
+
+func (p *KrigingInterpolator) readSource(src PointSource) ([]vec3d.T, []float64, error) {
+	ret := make([]vec3d.T, 0, 1000)
+	variance := make([]float64, 0, 1000)
+	weights := make([]float64, 0, 1000)
+	times := make([]float64, 0, 1000)
+	hasVariance, hasWeights, hasTimes := p.varianceProperty != nil, false, false
+
+	for {
+		s, err := src.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if hasTimes == s.Time.IsZero() && len(ret) > 0 {
+			return nil, nil, fmt.Errorf("sample %d: input mixes timed and untimed samples", len(ret))
+		}
+		hasTimes = !s.Time.IsZero()
+		ret = append(ret, vec3d.T{s.X, s.Y, s.Value})
+		variance = append(variance, s.Variance)
+		weights = append(weights, s.Weight)
+		times = append(times, timeValue(s.Time))
+		hasVariance = hasVariance || s.Variance != 0
+		hasWeights = hasWeights || s.Weight != 0
+	}
+
+	if r, ok := src.(interface{ Report() Report }); ok {
+		p.report = r.Report()
+	} else {
+		p.report = Report{Features: len(ret), Points: len(ret)}
+	}
+	if !hasVariance {
+		variance = nil
+	}
+	p.inputIndex = nil
+	p.inputWeights = nil
+	p.inputTimes = nil
+	if hasTimes {
+		p.inputTimes = times
+	}
+	if hasWeights {
+		for i, w := range weights {
+			if w == 0 {
+				weights[i] = 1
+			}
+		}
+		p.inputWeights = weights
+	}
+	return ret, variance, nil
+}
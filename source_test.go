@@ -0,0 +1,160 @@
+package kriging
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/flywave/go-geom"
+	"github.com/flywave/go-geom/general"
+
+	vec3d "github.com/flywave/go3d/float64/vec3"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readSamples(a *assert.Assertions, src PointSource) []Sample {
+	samples := []Sample{}
+	for {
+		s, err := src.Read()
+		if err == io.EOF {
+			break
+		}
+		a.Nil(err)
+		samples = append(samples, s)
+	}
+	return samples
+}
+
+func TestPointSource(t *testing.T) {
+	a := assert.New(t)
+
+	src := NewPositionSource([]vec3d.T{{1, 2, 3}, {4, 5, 6}})
+	s, err := src.Read()
+	a.Nil(err)
+	a.Equal(Sample{X: 1, Y: 2, Value: 3}, s)
+	_, err = src.Read()
+	a.Nil(err)
+	_, err = src.Read()
+	a.Equal(io.EOF, err)
+
+	n := 0
+	stream := PointSourceFunc(func() (Sample, error) {
+		if n == 3 {
+			return Sample{}, io.EOF
+		}
+		n++
+		return Sample{X: float64(n), Y: float64(n), Value: 10, Weight: float64(n % 2), Variance: 0.5}, nil
+	})
+	p := NewKrigingInterpolator(Options{Source: stream})
+	pos, variance, err := p.readInput()
+	a.Nil(err)
+	a.Equal([]vec3d.T{{1, 1, 10}, {2, 2, 10}, {3, 3, 10}}, pos)
+	a.Equal([]float64{0.5, 0.5, 0.5}, variance)
+	a.Equal([]float64{1, 1, 1}, p.inputWeights)
+	a.Equal(3, p.Report().Points)
+
+	p = NewKrigingInterpolator(Options{Source: NewSliceSource([]Sample{{X: 0, Y: 0, Value: 1, Weight: 2}, {X: 1, Y: 1, Value: 2}})})
+	_, variance, err = p.readInput()
+	a.Nil(err)
+	a.Nil(variance)
+	a.Equal([]float64{2, 1}, p.inputWeights)
+	p.computeWeights()
+	a.Equal(p.inputWeights, p.weights)
+}
+
+func TestFeatureSource(t *testing.T) {
+	a := assert.New(t)
+
+	fc := geom.NewFeatureCollection()
+	fc.AddFeature(geom.NewFeature(general.NewMultiPoint3([][]float64{{0, 0, 1}, {1, 0, 2}})))
+	fc.AddFeature(geom.NewFeature(general.NewMultiPolygon([][][][]float64{{{{0, 0}, {1, 0}, {1, 1}}}})))
+	fc.AddFeature(geom.NewFeature(geom.Collection{general.NewPoint([]float64{5, 5}), general.NewLineString3([][]float64{{6, 6, 7}})}))
+	for _, fea := range fc.Features {
+		fea.Properties["sigma"] = 0.25
+	}
+
+	prop := "sigma"
+	src := NewFeatureSource(fc, FeatureSourceOptions{VarianceProperty: &prop})
+	samples := []Sample{}
+	for {
+		s, err := src.Read()
+		if err == io.EOF {
+			break
+		}
+		a.Nil(err)
+		samples = append(samples, s)
+	}
+	a.Len(samples, 7)
+	a.Equal(Sample{X: 1, Y: 0, Value: 2, Variance: 0.25}, samples[1])
+	a.Equal(Sample{X: 1, Y: 1, Variance: 0.25}, samples[4])
+	a.Equal(Sample{X: 6, Y: 6, Value: 7, Variance: 0.25}, samples[6])
+	a.Equal(3, src.Report().Features)
+	a.Equal(7, src.Report().Points)
+}
+
+func TestTimedFeatureSource(t *testing.T) {
+	a := assert.New(t)
+
+	fc := geom.NewFeatureCollection()
+	for _, ts := range []interface{}{"2021-03-04 05:06:07", nil, 1000.5} {
+		fea := geom.NewFeature(general.NewPoint3([]float64{1, 2, 3}))
+		if ts != nil {
+			fea.Properties["time"] = ts
+		}
+		fc.AddFeature(fea)
+	}
+
+	prop := "time"
+	samples := readSamples(a, NewFeatureSource(fc, FeatureSourceOptions{TimeProperty: &prop}))
+	a.Len(samples, 2)
+	a.Equal(time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC), samples[0].Time)
+	a.Equal(time.Unix(1000, 5e8).UTC(), samples[1].Time)
+
+	p := NewKrigingInterpolator(Options{Input: fc, TimeProperty: &prop})
+	pos, _, err := p.readInput()
+	a.Nil(err)
+	a.Len(pos, 2)
+	a.Equal([]float64{timeValue(samples[0].Time), 1000.5}, p.inputTimes)
+	a.Equal([]SkippedFeature{{1, "missing timestamp"}}, p.Report().Skipped)
+}
+
+func TestFeatureSourceVariance(t *testing.T) {
+	a := assert.New(t)
+
+	fc := geom.NewFeatureCollection()
+	for _, v := range []interface{}{0.5, nil, "n/a", -1.0} {
+		fea := geom.NewFeature(general.NewPoint3([]float64{1, 2, 3}))
+		if v != nil {
+			fea.Properties["sigma"] = v
+		}
+		fc.AddFeature(fea)
+	}
+	fc.AddFeature(geom.NewFeature(general.NewLineString3([][]float64{{0, 0, 100}, {1, 0, 200}})))
+
+	prop := "sigma"
+	src := NewFeatureSource(fc, FeatureSourceOptions{VarianceProperty: &prop})
+	a.Equal([]Sample{{X: 1, Y: 2, Value: 3, Variance: 0.5}}, readSamples(a, src))
+	a.Equal([]SkippedFeature{{1, "missing variance"}, {2, "non-numeric variance"}, {3, "negative variance"}, {4, "missing variance"}}, src.Report().Skipped)
+}
+
+func TestBreaklineValueProperty(t *testing.T) {
+	a := assert.New(t)
+
+	fc := geom.NewFeatureCollection()
+	line := geom.NewFeature(general.NewLineString3([][]float64{{0, 0, 100}, {1, 0, 200}}))
+	line.Properties["breakline"] = "hard"
+	line.Properties["pm25"] = 7.0
+	fc.AddFeature(line)
+	line = geom.NewFeature(general.NewLineString3([][]float64{{0, 1, 100}, {1, 1, 200}}))
+	line.Properties["breakline"] = "soft"
+	fc.AddFeature(line)
+
+	bprop, value := "breakline", "pm25"
+	p := NewKrigingInterpolator(Options{Input: fc, BreaklineProperty: &bprop, ValueProperty: &value})
+	pos, _ := p.extractPosion()
+	a.Equal([]vec3d.T{{0, 0, 7}, {1, 0, 7}}, pos)
+	a.Len(p.breaklines, 1)
+	a.Equal([]vec3d.T{{0, 0, 7}, {1, 0, 7}}, p.breaklines[0].Vertices)
+	a.Equal([]SkippedFeature{{1, "missing value"}}, p.Report().Skipped)
+}
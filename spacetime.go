@@ -0,0 +1,277 @@
+package kriging
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	vec3d "github.com/flywave/go3d/float64/vec3"
+)
+
+type SpaceTimeModelType string
+
+const (
+	Separable  SpaceTimeModelType = "separable"
+	ProductSum SpaceTimeModelType = "productsum"
+	Metric     SpaceTimeModelType = "metric"
+)
+
+const (
+	default_space_lags = 30
+	default_time_lags  = 15
+)
+
+type SpaceTimeVariogram struct {
+	SpaceLags [][]float64
+	TimeLags  [][]float64
+	Gamma     [][]float64
+	Count     [][]int
+}
+
+type SpaceTimeKriging struct {
+	pos   []vec3d.T
+	times []float64
+
+	model      SpaceTimeModelType
+	space      *Kriging
+	time       *Kriging
+	sill       float64
+	k          float64
+	anisotropy float64
+	mean       float64
+
+	Variogram *SpaceTimeVariogram
+	M         []float64
+}
+
+func NewSpaceTime(pos []vec3d.T, times []float64) *SpaceTimeKriging {
+	return &SpaceTimeKriging{pos: pos, times: times}
+}
+
+func newSpaceTimeVariogram(pos []vec3d.T, times []float64, spaceLags, timeLags int) *SpaceTimeVariogram {
+	n := len(pos)
+	var maxH, maxU float64
+	for i := 0; i < n; i++ {
+		for j := 0; j < i; j++ {
+			maxH = math.Max(maxH, math.Hypot(pos[i][0]-pos[j][0], pos[i][1]-pos[j][1]))
+			maxU = math.Max(maxU, math.Abs(times[i]-times[j]))
+		}
+	}
+
+	v := &SpaceTimeVariogram{
+		SpaceLags: make([][]float64, spaceLags),
+		TimeLags:  make([][]float64, spaceLags),
+		Gamma:     make([][]float64, spaceLags),
+		Count:     make([][]int, spaceLags),
+	}
+	for i := 0; i < spaceLags; i++ {
+		v.SpaceLags[i] = make([]float64, timeLags)
+		v.TimeLags[i] = make([]float64, timeLags)
+		v.Gamma[i] = make([]float64, timeLags)
+		v.Count[i] = make([]int, timeLags)
+	}
+
+	bin := func(d, max float64, lags int) int {
+		if max == 0 {
+			return 0
+		}
+		b := int(d/(max/float64(lags-1)) + 0.5)
+		if b >= lags {
+			b = lags - 1
+		}
+		return b
+	}
+
+	for i := 0; i < n; i++ {
+		for j := 0; j < i; j++ {
+			h := math.Hypot(pos[i][0]-pos[j][0], pos[i][1]-pos[j][1])
+			u := math.Abs(times[i] - times[j])
+			s, t := bin(h, maxH, spaceLags), bin(u, maxU, timeLags)
+			dz := pos[i][2] - pos[j][2]
+			v.SpaceLags[s][t] += h
+			v.TimeLags[s][t] += u
+			v.Gamma[s][t] += 0.5 * dz * dz
+			v.Count[s][t]++
+		}
+	}
+
+	for s := range v.Gamma {
+		for t := range v.Gamma[s] {
+			if c := float64(v.Count[s][t]); c > 0 {
+				v.SpaceLags[s][t] /= c
+				v.TimeLags[s][t] /= c
+				v.Gamma[s][t] /= c
+			}
+		}
+	}
+	return v
+}
+
+func (v *SpaceTimeVariogram) spaceMarginal() ([]float64, []float64) {
+	var lag, semi []float64
+	for s := range v.Gamma {
+		if v.Count[s][0] > 0 {
+			lag = append(lag, v.SpaceLags[s][0])
+			semi = append(semi, v.Gamma[s][0])
+		}
+	}
+	return lag, semi
+}
+
+func (v *SpaceTimeVariogram) timeMarginal() ([]float64, []float64) {
+	var lag, semi []float64
+	for t := range v.Gamma[0] {
+		if v.Count[0][t] > 0 {
+			lag = append(lag, v.TimeLags[0][t])
+			semi = append(semi, v.Gamma[0][t])
+		}
+	}
+	return lag, semi
+}
+
+func (kri *SpaceTimeKriging) Train(model SpaceTimeModelType, spaceModel, timeModel ModelType, sigma2 float64) (*SpaceTimeKriging, error) {
+	n := len(kri.pos)
+	if n != len(kri.times) {
+		return nil, errors.New("positions and times do not match")
+	}
+	if n < 3 {
+		return nil, errors.New("not enough points")
+	}
+	kri.model = model
+
+	kri.mean = 0
+	for i := range kri.pos {
+		kri.mean += kri.pos[i][2]
+	}
+	kri.mean /= float64(n)
+	kri.sill = 0
+	for i := range kri.pos {
+		d := kri.pos[i][2] - kri.mean
+		kri.sill += d * d
+	}
+	kri.sill /= float64(n)
+	if kri.sill == 0 {
+		return nil, errors.New("observations have no variance")
+	}
+
+	kri.Variogram = newSpaceTimeVariogram(kri.pos, kri.times, default_space_lags, default_time_lags)
+
+	kri.space = New(nil)
+	lag, semi := kri.Variogram.spaceMarginal()
+	if err := kri.space.fitLags(spaceModel, lag, semi, 100); err != nil {
+		return nil, errors.New("not enough spatial lags")
+	}
+	kri.time = New(nil)
+	lag, semi = kri.Variogram.timeMarginal()
+	if err := kri.time.fitLags(timeModel, lag, semi, 100); err != nil {
+		return nil, errors.New("not enough temporal lags")
+	}
+
+	cs, ct := kri.space.covariance(0), kri.time.covariance(0)
+	if cs <= 0 || ct <= 0 {
+		return nil, errors.New("invalid marginal variogram")
+	}
+	kri.k = (cs + ct - kri.sill) / (cs * ct)
+	if kmax := 1 / math.Max(cs, ct); kri.k > kmax {
+		kri.k = kmax
+	}
+	if kri.k < 0 {
+		kri.k = 0
+	}
+	kri.anisotropy = 1
+	if kri.time.rangex > 0 {
+		kri.anisotropy = kri.space.rangex / kri.time.rangex
+	}
+
+	C := make([]float64, n*n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < i; j++ {
+			C[i*n+j] = kri.covariance(
+				math.Hypot(kri.pos[i][0]-kri.pos[j][0], kri.pos[i][1]-kri.pos[j][1]),
+				math.Abs(kri.times[i]-kri.times[j]))
+			C[j*n+i] = C[i*n+j]
+		}
+		C[i*n+i] = kri.covariance(0, 0) + kri.nuggetEffect() + sigma2
+	}
+
+	t := make([]float64, n)
+	for i := range kri.pos {
+		t[i] = kri.pos[i][2] - kri.mean
+	}
+
+	M, err := matrixSolveVec(C, t, n)
+	if err != nil {
+		return nil, err
+	}
+	kri.M = M
+
+	return kri, nil
+}
+
+func (kri *SpaceTimeKriging) covariance(h, u float64) float64 {
+	switch kri.model {
+	case ProductSum:
+		gs, gt := kri.space.variogram(h), kri.time.variogram(u)
+		return kri.sill - (gs + gt - kri.k*gs*gt)
+	case Metric:
+		return kri.sill / kri.space.covariance(0) * kri.space.continuousCovariance(math.Hypot(h, kri.anisotropy*u))
+	default:
+		return kri.sill * kri.space.continuousCovariance(h) / kri.space.covariance(0) * kri.time.continuousCovariance(u) / kri.time.covariance(0)
+	}
+}
+
+func (kri *SpaceTimeKriging) nuggetEffect() float64 {
+	c0 := kri.covariance(0, 0)
+	return math.Max(kri.sill-c0, 0) + default_solver_nugget*c0
+}
+
+func (kri *SpaceTimeKriging) Predict(x, y, t float64) float64 {
+	ret := kri.mean
+	for i := range kri.pos {
+		h := math.Hypot(x-kri.pos[i][0], y-kri.pos[i][1])
+		u := math.Abs(t - kri.times[i])
+		ret += kri.covariance(h, u) * kri.M[i]
+	}
+	return ret
+}
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+func parseTime(v interface{}) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case int64:
+		return float64(t), true
+	case int:
+		return float64(t), true
+	case time.Time:
+		return timeValue(t), true
+	case string:
+		for _, layout := range timeLayouts {
+			if ts, err := time.Parse(layout, t); err == nil {
+				return timeValue(ts), true
+			}
+		}
+		if f, err := strconv.ParseFloat(strings.TrimSpace(t), 64); err == nil {
+			return f, true
+		}
+	}
+	return 0, false
+}
+
+func timeValue(t time.Time) float64 {
+	return float64(t.UnixNano()) / 1e9
+}
+
+func secondsTime(v float64) time.Time {
+	sec := math.Floor(v)
+	return time.Unix(int64(sec), int64(math.Round((v-sec)*1e9))).UTC()
+}
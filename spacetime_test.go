@@ -0,0 +1,60 @@
+package kriging
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	vec3d "github.com/flywave/go3d/float64/vec3"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpaceTimeKriging(t *testing.T) {
+	a := assert.New(t)
+
+	var pos []vec3d.T
+	var times []float64
+	for h := 0; h < 6; h++ {
+		for i := 0; i < 5; i++ {
+			for j := 0; j < 5; j++ {
+				x, y, ts := float64(i)*10, float64(j)*10, float64(h)*3600
+				pos = append(pos, vec3d.T{x, y, 20 + x/10 + math.Sin(ts/7200)*3})
+				times = append(times, ts)
+			}
+		}
+	}
+
+	for _, model := range []SpaceTimeModelType{Separable, ProductSum, Metric} {
+		kri, err := NewSpaceTime(pos, times).Train(model, Spherical, Exponential, 0)
+		a.Nil(err, model)
+		if err != nil {
+			continue
+		}
+		a.InDelta(pos[7][2], kri.Predict(pos[7][0], pos[7][1], times[7]), 1e-3, model)
+		a.InDelta(kri.covariance(1e-9, 3600), kri.covariance(0, 3600), 1e-6, model)
+		a.InDelta(kri.covariance(1e-9, 1e-9), kri.covariance(0, 0), 1e-6, model)
+		a.True(kri.nuggetEffect() > 0, model)
+
+		v := kri.Predict(25, 25, 5400)
+		a.False(math.IsNaN(v), model)
+		a.True(v > 15 && v < 30, model)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	a := assert.New(t)
+
+	v, ok := parseTime("2021-03-04T05:06:07Z")
+	a.True(ok)
+	a.Equal(timeValue(time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)), v)
+
+	v, ok = parseTime(float64(1000))
+	a.True(ok)
+	a.Equal(1000.0, v)
+
+	_, ok = parseTime("yesterday")
+	a.False(ok)
+	_, ok = parseTime(nil)
+	a.False(ok)
+}
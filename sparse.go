@@ -0,0 +1,109 @@
+package kriging
+
+import (
+	"fmt"
+	"math"
+)
+
+type csrMatrix struct {
+	n       int
+	indptr  []int
+	indices []int
+	data    []float64
+}
+
+func newCSRMatrix(n int) *csrMatrix {
+	return &csrMatrix{n: n, indptr: make([]int, 1, n+1)}
+}
+
+func (m *csrMatrix) appendRow(indices []int, values []float64) {
+	m.indices = append(m.indices, indices...)
+	m.data = append(m.data, values...)
+	m.indptr = append(m.indptr, len(m.indices))
+}
+
+func (m *csrMatrix) Diagonal() []float64 {
+	d := make([]float64, m.n)
+	for i := 0; i < m.n; i++ {
+		for k := m.indptr[i]; k < m.indptr[i+1]; k++ {
+			if m.indices[k] == i {
+				d[i] = m.data[k]
+			}
+		}
+	}
+	return d
+}
+
+func (m *csrMatrix) MulVec(x, y []float64) {
+	for i := 0; i < m.n; i++ {
+		sum := 0.0
+		for k := m.indptr[i]; k < m.indptr[i+1]; k++ {
+			sum += m.data[k] * x[m.indices[k]]
+		}
+		y[i] = sum
+	}
+}
+
+func conjugateGradient(mul func(x, y []float64), b, diag []float64, tol float64, maxIter int) ([]float64, error) {
+	n := len(b)
+	x := make([]float64, n)
+	r := make([]float64, n)
+	z := make([]float64, n)
+	p := make([]float64, n)
+	q := make([]float64, n)
+
+	precond := func(r, z []float64) {
+		for i := range r {
+			if diag != nil && diag[i] != 0 {
+				z[i] = r[i] / diag[i]
+			} else {
+				z[i] = r[i]
+			}
+		}
+	}
+
+	copy(r, b)
+	bnorm := vecNorm(b)
+	if bnorm == 0 {
+		return x, nil
+	}
+	precond(r, z)
+	copy(p, z)
+	rz := vecDot(r, z)
+
+	for it := 0; it < maxIter; it++ {
+		mul(p, q)
+		pq := vecDot(p, q)
+		if pq <= 0 || math.IsNaN(pq) {
+			return nil, fmt.Errorf("conjugate gradient broke down after %d iterations, matrix is not positive definite", it)
+		}
+		alpha := rz / pq
+		for i := range x {
+			x[i] += alpha * p[i]
+			r[i] -= alpha * q[i]
+		}
+		if vecNorm(r)/bnorm < tol {
+			return x, nil
+		}
+		precond(r, z)
+		rzNew := vecDot(r, z)
+		beta := rzNew / rz
+		rz = rzNew
+		for i := range p {
+			p[i] = z[i] + beta*p[i]
+		}
+	}
+	return nil, fmt.Errorf("conjugate gradient did not converge after %d iterations (relative residual %.3g)", maxIter, vecNorm(r)/bnorm)
+}
+
+func vecDot(x, y []float64) float64 {
+	sum := 0.0
+	for i := range x {
+		sum += x[i] * y[i]
+	}
+	return sum
+}
+
+func vecNorm(x []float64) float64 {
+	return math.Sqrt(vecDot(x, x))
+}
@@ -0,0 +1,388 @@
+package kriging
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+const (
+	default_sqlite_header_size = 100
+	default_sqlite_magic       = "SQLite format 3\x00"
+)
+
+type sqliteFile struct {
+	r        io.ReaderAt
+	pageSize int
+	usable   int
+}
+
+type sqliteTable struct {
+	columns []string
+	real    []bool
+	rowid   int
+	root    int
+}
+
+func openSQLite(r io.ReaderAt) (*sqliteFile, error) {
+	header := make([]byte, default_sqlite_header_size)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("sqlite header: %w", err)
+	}
+	if string(header[:16]) != default_sqlite_magic {
+		return nil, errors.New("not a SQLite database")
+	}
+	pageSize := int(binary.BigEndian.Uint16(header[16:]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 {
+		return nil, errors.New("invalid SQLite page size")
+	}
+	return &sqliteFile{r: r, pageSize: pageSize, usable: pageSize - int(header[20])}, nil
+}
+
+func (db *sqliteFile) page(n int) ([]byte, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("invalid SQLite page %d", n)
+	}
+	buf := make([]byte, db.pageSize)
+	if _, err := db.r.ReadAt(buf, int64(n-1)*int64(db.pageSize)); err != nil {
+		return nil, fmt.Errorf("sqlite page %d: %w", n, err)
+	}
+	return buf, nil
+}
+
+func sqliteVarint(b []byte) (int64, int) {
+	var v uint64
+	for i := 0; i < 8 && i < len(b); i++ {
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i] < 0x80 {
+			return int64(v), i + 1
+		}
+	}
+	if len(b) < 9 {
+		return int64(v), len(b)
+	}
+	return int64(v<<8 | uint64(b[8])), 9
+}
+
+func (db *sqliteFile) payload(page []byte, offset int) ([]byte, error) {
+	page = page[:db.usable]
+	size, n := sqliteVarint(page[offset:])
+	offset += n
+	_, n = sqliteVarint(page[offset:])
+	offset += n
+
+	p := int(size)
+	if p < 0 {
+		return nil, errors.New("invalid sqlite payload size")
+	}
+	x := db.usable - 35
+	if p <= x {
+		if offset+p > len(page) {
+			return nil, errors.New("sqlite cell overflows page")
+		}
+		return page[offset : offset+p], nil
+	}
+
+	m := (db.usable-12)*32/255 - 23
+	local := m + (p-m)%(db.usable-4)
+	if local > x {
+		local = m
+	}
+	if offset+local+4 > len(page) {
+		return nil, errors.New("sqlite cell overflows page")
+	}
+	data := append(make([]byte, 0, p), page[offset:offset+local]...)
+	next := int(binary.BigEndian.Uint32(page[offset+local:]))
+	for len(data) < p && next != 0 {
+		ov, err := db.page(next)
+		if err != nil {
+			return nil, err
+		}
+		next = int(binary.BigEndian.Uint32(ov))
+		data = append(data, ov[4:min(db.usable, 4+p-len(data))]...)
+	}
+	if len(data) < p {
+		return nil, errors.New("sqlite overflow chain is truncated")
+	}
+	return data, nil
+}
+
+func sqliteRecord(data []byte) ([]interface{}, error) {
+	hs, n := sqliteVarint(data)
+	if hs < int64(n) || hs > int64(len(data)) {
+		return nil, errors.New("invalid sqlite record header")
+	}
+	types := []int64{}
+	for pos := n; pos < int(hs); {
+		t, k := sqliteVarint(data[pos:])
+		types = append(types, t)
+		pos += k
+	}
+
+	values := make([]interface{}, len(types))
+	pos := int(hs)
+	for i, t := range types {
+		size := 0
+		switch {
+		case t >= 1 && t <= 4:
+			size = int(t)
+		case t == 5:
+			size = 6
+		case t == 6 || t == 7:
+			size = 8
+		case t >= 12:
+			size = int(t-12) / 2
+		}
+		if pos+size > len(data) {
+			return nil, errors.New("truncated sqlite record")
+		}
+		b := data[pos : pos+size]
+		pos += size
+
+		switch {
+		case t == 0:
+			values[i] = nil
+		case t >= 1 && t <= 6:
+			v := int64(int8(b[0]))
+			for _, c := range b[1:] {
+				v = v<<8 | int64(c)
+			}
+			values[i] = v
+		case t == 7:
+			values[i] = math.Float64frombits(binary.BigEndian.Uint64(b))
+		case t == 8:
+			values[i] = int64(0)
+		case t == 9:
+			values[i] = int64(1)
+		case t >= 12 && t%2 == 0:
+			values[i] = append([]byte{}, b...)
+		case t >= 13:
+			values[i] = string(b)
+		}
+	}
+	return values, nil
+}
+
+func (db *sqliteFile) scan(root int, fn func(rowid int64, values []interface{}) error) error {
+	return db.walk(root, map[int]bool{}, fn)
+}
+
+func (db *sqliteFile) walk(root int, visited map[int]bool, fn func(rowid int64, values []interface{}) error) error {
+	if visited[root] {
+		return fmt.Errorf("sqlite b-tree page %d is referenced twice", root)
+	}
+	visited[root] = true
+	page, err := db.page(root)
+	if err != nil {
+		return err
+	}
+	h := 0
+	if root == 1 {
+		h = default_sqlite_header_size
+	}
+	kind := page[h]
+	cells := int(binary.BigEndian.Uint16(page[h+3:]))
+	ptrs := h + 8
+	if kind == 0x05 {
+		ptrs = h + 12
+	}
+	if ptrs+2*cells > db.usable {
+		return fmt.Errorf("sqlite page %d: cell pointer array overflows page", root)
+	}
+
+	for i := 0; i < cells; i++ {
+		offset := int(binary.BigEndian.Uint16(page[ptrs+2*i:]))
+		if offset < ptrs+2*cells || offset+4 > db.usable {
+			return fmt.Errorf("sqlite page %d: cell offset %d is out of range", root, offset)
+		}
+		switch kind {
+		case 0x05:
+			if err := db.walk(int(binary.BigEndian.Uint32(page[offset:])), visited, fn); err != nil {
+				return err
+			}
+		case 0x0d:
+			_, n := sqliteVarint(page[offset:db.usable])
+			rowid, _ := sqliteVarint(page[offset+n : db.usable])
+			data, err := db.payload(page, offset)
+			if err != nil {
+				return err
+			}
+			values, err := sqliteRecord(data)
+			if err != nil {
+				return err
+			}
+			if err := fn(rowid, values); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unexpected sqlite page type %d", kind)
+		}
+	}
+	if kind == 0x05 {
+		return db.walk(int(binary.BigEndian.Uint32(page[h+8:])), visited, fn)
+	}
+	return nil
+}
+
+func sqliteQuote(c byte) byte {
+	switch c {
+	case '"', '`', '\'':
+		return c
+	case '[':
+		return ']'
+	}
+	return 0
+}
+
+func sqliteIdentifier(item string) (string, string) {
+	item = strings.TrimSpace(item)
+	if item == "" {
+		return "", ""
+	}
+	end := sqliteQuote(item[0])
+	if end == 0 {
+		if i := strings.IndexAny(item, " \t\r\n"); i >= 0 {
+			return item[:i], item[i:]
+		}
+		return item, ""
+	}
+	name := []byte{}
+	for i := 1; i < len(item); i++ {
+		if item[i] != end {
+			name = append(name, item[i])
+			continue
+		}
+		if end != ']' && i+1 < len(item) && item[i+1] == end {
+			name = append(name, end)
+			i++
+			continue
+		}
+		return string(name), item[i+1:]
+	}
+	return string(name), ""
+}
+
+func sqliteColumns(sql string) ([]string, []bool, int) {
+	start, end := strings.Index(sql, "("), strings.LastIndex(sql, ")")
+	if start < 0 || end <= start {
+		return nil, nil, -1
+	}
+	items := []string{}
+	depth, last := 0, start+1
+	var quote byte
+	for i := start + 1; i < end; i++ {
+		if quote != 0 {
+			if sql[i] == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch sql[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				items = append(items, sql[last:i])
+				last = i + 1
+			}
+		default:
+			quote = sqliteQuote(sql[i])
+		}
+	}
+	items = append(items, sql[last:end])
+
+	columns, real, rowid := []string{}, []bool{}, -1
+	for _, item := range items {
+		name, rest := sqliteIdentifier(item)
+		if name == "" {
+			continue
+		}
+		if sqliteQuote(strings.TrimSpace(item)[0]) == 0 {
+			switch strings.ToUpper(name) {
+			case "CONSTRAINT", "PRIMARY", "UNIQUE", "CHECK", "FOREIGN":
+				continue
+			}
+		}
+		fields := strings.Fields(rest)
+		upper, kind := strings.ToUpper(rest), ""
+		if len(fields) > 0 {
+			kind = strings.ToUpper(fields[0])
+		}
+		if kind == "INTEGER" && strings.Contains(upper, "PRIMARY KEY") {
+			rowid = len(columns)
+		}
+		columns = append(columns, name)
+		real = append(real, sqliteRealAffinity(kind))
+	}
+	return columns, real, rowid
+}
+
+func sqliteRealAffinity(kind string) bool {
+	for _, s := range []string{"INT", "CHAR", "CLOB", "TEXT", "BLOB"} {
+		if strings.Contains(kind, s) {
+			return false
+		}
+	}
+	for _, s := range []string{"REAL", "FLOA", "DOUB"} {
+		if strings.Contains(kind, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (db *sqliteFile) table(name string) (*sqliteTable, error) {
+	var found *sqliteTable
+	err := db.scan(1, func(_ int64, values []interface{}) error {
+		if len(values) < 5 || values[0] != "table" {
+			return nil
+		}
+		if n, _ := values[1].(string); !strings.EqualFold(n, name) {
+			return nil
+		}
+		root, _ := values[3].(int64)
+		sql, _ := values[4].(string)
+		columns, real, rowid := sqliteColumns(sql)
+		found = &sqliteTable{columns: columns, real: real, rowid: rowid, root: int(root)}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("sqlite table %q not found", name)
+	}
+	return found, nil
+}
+
+func (db *sqliteFile) rows(name string, fn func(row map[string]interface{}) error) error {
+	t, err := db.table(name)
+	if err != nil {
+		return err
+	}
+	return db.scan(t.root, func(rowid int64, values []interface{}) error {
+		row := make(map[string]interface{}, len(t.columns))
+		for i, c := range t.columns {
+			if i >= len(values) {
+				continue
+			}
+			if v, ok := values[i].(int64); ok && t.real[i] {
+				row[c] = float64(v)
+			} else {
+				row[c] = values[i]
+			}
+		}
+		if t.rowid >= 0 {
+			row[t.columns[t.rowid]] = rowid
+		}
+		return fn(row)
+	})
+}
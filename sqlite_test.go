@@ -0,0 +1,72 @@
+package kriging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLiteColumns(t *testing.T) {
+	a := assert.New(t)
+
+	columns, real, rowid := sqliteColumns(`CREATE TABLE "my points" ("fid" INTEGER PRIMARY KEY AUTOINCREMENT, "pm 2.5" REAL, [depth (m)] DOUBLE,
+		"a,""b" TEXT DEFAULT 'x, y)', geom POINT, CONSTRAINT pk UNIQUE (fid))`)
+	a.Equal([]string{"fid", "pm 2.5", "depth (m)", `a,"b`, "geom"}, columns)
+	a.Equal([]bool{false, true, true, false, false}, real)
+	a.Equal(0, rowid)
+}
+
+func TestSQLiteCycle(t *testing.T) {
+	a := assert.New(t)
+
+	buf := make([]byte, 1024)
+	copy(buf, default_sqlite_magic)
+	binary.BigEndian.PutUint16(buf[16:], 512)
+	buf[default_sqlite_header_size] = 0x05
+	binary.BigEndian.PutUint32(buf[default_sqlite_header_size+8:], 2)
+	buf[512] = 0x05
+	binary.BigEndian.PutUint32(buf[512+8:], 1)
+
+	db, err := openSQLite(bytes.NewReader(buf))
+	a.Nil(err)
+	err = db.scan(1, func(int64, []interface{}) error { return nil })
+	a.EqualError(err, "sqlite b-tree page 1 is referenced twice")
+}
+
+func TestSQLiteCorruptPage(t *testing.T) {
+	a := assert.New(t)
+
+	leaf := func(cells int, offset int, cell []byte) []byte {
+		buf := make([]byte, 512)
+		copy(buf, default_sqlite_magic)
+		binary.BigEndian.PutUint16(buf[16:], 512)
+		buf[20] = 16
+		h := default_sqlite_header_size
+		buf[h] = 0x0d
+		binary.BigEndian.PutUint16(buf[h+3:], uint16(cells))
+		binary.BigEndian.PutUint16(buf[h+8:], uint16(offset))
+		copy(buf[offset:], cell)
+		return buf
+	}
+
+	for _, c := range []struct {
+		buf []byte
+		err string
+	}{
+		{leaf(0xffff, 400, nil), "sqlite page 1: cell pointer array overflows page"},
+		{leaf(1, 500, nil), "sqlite page 1: cell offset 500 is out of range"},
+		{leaf(1, 50, nil), "sqlite page 1: cell offset 50 is out of range"},
+		{leaf(1, 480, []byte{100, 1}), "sqlite cell overflows page"},
+		{leaf(1, 480, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 1}), "invalid sqlite payload size"},
+		{leaf(1, 480, []byte{3, 1, 9, 0, 0}), "invalid sqlite record header"},
+	} {
+		db, err := openSQLite(bytes.NewReader(c.buf))
+		a.Nil(err)
+		a.NotPanics(func() {
+			err = db.scan(1, func(int64, []interface{}) error { return nil })
+		})
+		a.EqualError(err, c.err)
+	}
+}
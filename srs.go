@@ -0,0 +1,65 @@
+package kriging
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/flywave/go-geo"
+)
+
+var (
+	wktAuthority = regexp.MustCompile(`(?i)(?:AUTHORITY|ID)\[\s*"EPSG"\s*,\s*"?(\d+)"?\s*\]\s*\]*\s*$`)
+	wktUTM       = regexp.MustCompile(`(?i)PROJCS\[\s*"(WGS[ _]?(?:19)?84|NAD[ _]?(?:19)?83)[ _/]+UTM[ _]zone[ _](\d+)([NS])"`)
+	wktNames     = map[string]int{
+		"GCS_WGS_1984":                              4326,
+		"WGS 84":                                    4326,
+		"WGS_1984_Web_Mercator_Auxiliary_Sphere":    3857,
+		"WGS 84 / Pseudo-Mercator":                  3857,
+		"GCS_China_Geodetic_Coordinate_System_2000": 4490,
+		"China Geodetic Coordinate System 2000":     4490,
+	}
+)
+
+func newProj(code interface{}) geo.Proj {
+	proj := geo.NewProj(code)
+	if p, ok := proj.(*geo.SRSProj4); ok && p == nil {
+		return nil
+	}
+	return proj
+}
+
+func wktEPSG(wkt string) int {
+	wkt = strings.TrimSpace(wkt)
+	if m := wktAuthority.FindStringSubmatch(wkt); m != nil {
+		code, _ := strconv.Atoi(m[1])
+		return code
+	}
+	if m := wktUTM.FindStringSubmatch(wkt); m != nil {
+		zone, _ := strconv.Atoi(m[2])
+		north := strings.EqualFold(m[3], "N")
+		switch {
+		case strings.HasPrefix(strings.ToUpper(m[1]), "NAD"):
+			if north {
+				return 26900 + zone
+			}
+		case north:
+			return 32600 + zone
+		default:
+			return 32700 + zone
+		}
+	}
+	if start := strings.Index(wkt, "[\""); start > 0 {
+		if end := strings.Index(wkt[start+2:], "\""); end > 0 {
+			return wktNames[wkt[start+2:start+2+end]]
+		}
+	}
+	return 0
+}
+
+func wktProj(wkt string) geo.Proj {
+	if epsg := wktEPSG(wkt); epsg != 0 {
+		return newProj(epsg)
+	}
+	return newProj(strings.TrimSpace(wkt))
+}
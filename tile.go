@@ -0,0 +1,425 @@
+package kriging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/flywave/go-cog"
+	"github.com/flywave/go-geo"
+	"github.com/hhrutter/lzw"
+
+	vec2d "github.com/flywave/go3d/float64/vec2"
+)
+
+const (
+	default_tile_overlap     = 16
+	default_tile_min_points  = 16
+	default_output_tile_size = 256
+)
+
+type tileLayout struct {
+	width      int
+	height     int
+	tileWidth  int
+	tileHeight int
+	overlap    int
+	origin     vec2d.T
+	pixelSize  [2]float64
+}
+
+func (t *tileLayout) position(col, row int) vec2d.T {
+	return vec2d.T{
+		t.origin[0] + t.pixelSize[0]*float64(col),
+		t.origin[1] + t.pixelSize[1]*float64(t.height-1-row),
+	}
+}
+
+func (t *tileLayout) Rect() vec2d.Rect {
+	return vec2d.Rect{Min: t.position(0, t.height-1), Max: t.position(t.width-1, 0)}
+}
+
+func featherWeight(i, lo, hi, n, overlap int) float64 {
+	d := overlap + 1
+	if lo > 0 && i-lo+1 < d {
+		d = i - lo + 1
+	}
+	if hi < n && hi-i < d {
+		d = hi - i
+	}
+	return float64(d)
+}
+
+type tileRow struct {
+	sum    []float64
+	weight []float64
+}
+
+type tiffEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	data  []byte
+}
+
+func tiffValues(tag, typ uint16, count int, values interface{}) tiffEntry {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, values)
+	return tiffEntry{tag: tag, typ: typ, count: uint32(count), data: buf.Bytes()}
+}
+
+func tiffShorts(tag uint16, values ...uint16) tiffEntry {
+	return tiffValues(tag, 3, len(values), values)
+}
+
+func tiffLongs(tag uint16, values ...uint32) tiffEntry {
+	return tiffValues(tag, 4, len(values), values)
+}
+
+func tiffDoubles(tag uint16, values ...float64) tiffEntry {
+	return tiffValues(tag, 12, len(values), values)
+}
+
+func tiffASCII(tag uint16, value string) tiffEntry {
+	return tiffEntry{tag: tag, typ: 2, count: uint32(len(value) + 1), data: append([]byte(value), 0)}
+}
+
+type tiledWriter struct {
+	w        io.WriteSeeker
+	width    int
+	height   int
+	tileSize int
+	nodata   float64
+	band     []float64
+	rows     int
+	next     int
+	offset   int64
+	offsets  []uint32
+	counts   []uint32
+}
+
+func newTiledWriter(w io.WriteSeeker, width, height, tileSize int, nodata float64) (*tiledWriter, error) {
+	if _, err := w.Write([]byte{'I', 'I', 42, 0, 0, 0, 0, 0}); err != nil {
+		return nil, err
+	}
+	t := &tiledWriter{w: w, width: width, height: height, tileSize: tileSize, nodata: nodata, offset: 8}
+	t.band = make([]float64, t.tilesAcross()*tileSize*tileSize)
+	t.reset()
+	return t, nil
+}
+
+func (t *tiledWriter) tilesAcross() int {
+	return (t.width + t.tileSize - 1) / t.tileSize
+}
+
+func (t *tiledWriter) reset() {
+	for i := range t.band {
+		t.band[i] = t.nodata
+	}
+	t.rows = 0
+}
+
+func (t *tiledWriter) WriteRow(row int, values []float64) error {
+	if row != t.next || len(values) != t.width {
+		return fmt.Errorf("row %d out of order", row)
+	}
+	t.next++
+	copy(t.band[t.rows*t.tilesAcross()*t.tileSize:], values)
+	t.rows++
+	if t.rows == t.tileSize {
+		return t.flush()
+	}
+	return nil
+}
+
+func (t *tiledWriter) flush() error {
+	stride := t.tilesAcross() * t.tileSize
+	tile := make([]float64, t.tileSize*t.tileSize)
+	var buf bytes.Buffer
+	for tx := 0; tx < t.tilesAcross(); tx++ {
+		for y := 0; y < t.tileSize; y++ {
+			copy(tile[y*t.tileSize:(y+1)*t.tileSize], t.band[y*stride+tx*t.tileSize:])
+		}
+		buf.Reset()
+		dst := lzw.NewWriter(&buf, true)
+		if err := binary.Write(dst, binary.LittleEndian, tile); err != nil {
+			return err
+		}
+		if err := dst.Close(); err != nil {
+			return err
+		}
+		if t.offset+int64(buf.Len()) > math.MaxUint32 {
+			return errors.New("tiled output exceeds 4 GiB")
+		}
+		t.offsets = append(t.offsets, uint32(t.offset))
+		t.counts = append(t.counts, uint32(buf.Len()))
+		n, err := buf.WriteTo(t.w)
+		t.offset += n
+		if err != nil {
+			return err
+		}
+	}
+	t.reset()
+	return nil
+}
+
+func (t *tiledWriter) Close(rect vec2d.Rect, ifd *cog.IFD) error {
+	if t.next != t.height {
+		return fmt.Errorf("wrote %d of %d rows", t.next, t.height)
+	}
+	if t.rows > 0 {
+		if err := t.flush(); err != nil {
+			return err
+		}
+	}
+
+	entries := []tiffEntry{
+		tiffLongs(256, uint32(t.width)),
+		tiffLongs(257, uint32(t.height)),
+		tiffShorts(258, 64),
+		tiffShorts(259, uint16(cog.CTLZW)),
+		tiffShorts(262, cog.PI_BlackIsZero),
+		tiffShorts(277, 1),
+		tiffShorts(284, 1),
+		tiffLongs(322, uint32(t.tileSize)),
+		tiffLongs(323, uint32(t.tileSize)),
+		tiffLongs(324, t.offsets...),
+		tiffLongs(325, t.counts...),
+		tiffShorts(339, 3),
+		tiffDoubles(33550, (rect.Max[0]-rect.Min[0])/float64(t.width), (rect.Max[1]-rect.Min[1])/float64(t.height), 0),
+		tiffDoubles(33922, 0, 0, 0, rect.Min[0], rect.Max[1], 0),
+		tiffShorts(34735, ifd.GeoKeyDirectoryTag...),
+	}
+	if len(ifd.GeoDoubleParamsTag) > 0 {
+		entries = append(entries, tiffDoubles(34736, ifd.GeoDoubleParamsTag...))
+	}
+	if ifd.GeoAsciiParamsTag != "" {
+		entries = append(entries, tiffASCII(34737, ifd.GeoAsciiParamsTag))
+	}
+	if ifd.NoData != "" {
+		entries = append(entries, tiffASCII(42113, ifd.NoData))
+	}
+
+	start := t.offset + t.offset%2
+	var dir, extra bytes.Buffer
+	dir.Write(make([]byte, start-t.offset))
+	binary.Write(&dir, binary.LittleEndian, uint16(len(entries)))
+	ext := start + 2 + 12*int64(len(entries)) + 4
+	for _, e := range entries {
+		binary.Write(&dir, binary.LittleEndian, e.tag)
+		binary.Write(&dir, binary.LittleEndian, e.typ)
+		binary.Write(&dir, binary.LittleEndian, e.count)
+		if len(e.data) <= 4 {
+			dir.Write(append(e.data, make([]byte, 4-len(e.data))...))
+			continue
+		}
+		binary.Write(&dir, binary.LittleEndian, uint32(ext+int64(extra.Len())))
+		extra.Write(e.data)
+		if extra.Len()%2 == 1 {
+			extra.WriteByte(0)
+		}
+	}
+	binary.Write(&dir, binary.LittleEndian, uint32(0))
+	if ext+int64(extra.Len()) > math.MaxUint32 {
+		return errors.New("tiled output exceeds 4 GiB")
+	}
+	if _, err := dir.WriteTo(t.w); err != nil {
+		return err
+	}
+	if _, err := extra.WriteTo(t.w); err != nil {
+		return err
+	}
+
+	if _, err := t.w.Seek(4, io.SeekStart); err != nil {
+		return err
+	}
+	return binary.Write(t.w, binary.LittleEndian, uint32(start))
+}
+
+func (p *KrigingInterpolator) processTiled() (vec2d.Rect, geo.Proj, error) {
+	width, height, ok := p.cacleExtent()
+	if !ok || width <= 0 || height <= 0 {
+		return vec2d.Rect{}, nil, errors.New("gen grid error")
+	}
+
+	ps := caclulatePixelSize(width, height, p.bounds)
+	layout := &tileLayout{
+		width:      width,
+		height:     height,
+		tileWidth:  int(p.tileSize[0]),
+		tileHeight: int(p.tileSize[1]),
+		overlap:    p.tileOverlap,
+		origin:     p.bounds.Min,
+		pixelSize:  [2]float64{ps[0], ps[1]},
+	}
+	if layout.tileWidth <= 0 || layout.tileHeight <= 0 {
+		return vec2d.Rect{}, nil, errors.New("invalid tile size")
+	}
+
+	tile, err := p.tilePredictor(layout)
+	if err != nil {
+		return vec2d.Rect{}, nil, err
+	}
+
+	nodata, err := strconv.ParseFloat(p.nodata, 64)
+	if err != nil {
+		nodata = default_no_data
+	}
+
+	f, err := os.Create(p.output)
+	if err != nil {
+		return vec2d.Rect{}, nil, err
+	}
+	defer f.Close()
+
+	w, err := newTiledWriter(f, width, height, default_output_tile_size, nodata)
+	if err != nil {
+		return vec2d.Rect{}, nil, err
+	}
+	if err := p.resampleTiles(layout, tile, w.WriteRow); err != nil {
+		return vec2d.Rect{}, nil, err
+	}
+
+	ifd := &cog.IFD{NoData: p.nodata}
+	if err := ifd.SetEPSG(4326, true); err != nil {
+		return vec2d.Rect{}, nil, err
+	}
+	rect := layout.Rect()
+	if err := w.Close(rect, ifd); err != nil {
+		return vec2d.Rect{}, nil, err
+	}
+	return rect, epsg4326, nil
+}
+
+func (p *KrigingInterpolator) tilePredictor(layout *tileLayout) (func(x0, y0, x1, y1 int) (Predictor, error), error) {
+	if p.method != KrigingMethod || newBreaklineIndex(p.breaklines) != nil {
+		predictor, err := p.computePredictor()
+		if err != nil {
+			return nil, err
+		}
+		return func(x0, y0, x1, y1 int) (Predictor, error) {
+			return predictor, nil
+		}, nil
+	}
+
+	global := NewWithVariance(p.inputPos, p.inputVar)
+	global.SetWeights(p.weights)
+	if p.solver != nil {
+		global.SetSolver(*p.solver)
+	}
+	if err := global.fitVariogram(p.model, 100); err != nil {
+		return nil, err
+	}
+
+	idx := newPointIndex(p.inputPos, suggestCellSize(p.inputPos, default_tile_min_points))
+	return func(x0, y0, x1, y1 int) (Predictor, error) {
+		kri, err := tileKriging(global, idx, layout, x0, y0, x1, y1)
+		if err != nil {
+			return nil, err
+		}
+		return kri, nil
+	}, nil
+}
+
+func (p *KrigingInterpolator) resampleTiles(layout *tileLayout, tile func(x0, y0, x1, y1 int) (Predictor, error), fn func(row int, values []float64) error) error {
+	outside := p.outsideValue()
+	ov := layout.overlap
+
+	rows := make(map[int]*tileRow)
+	next := 0
+	flush := func(end int) error {
+		for ; next < end; next++ {
+			r := rows[next]
+			values := make([]float64, layout.width)
+			for i := range values {
+				if r.weight[i] > 0 {
+					values[i] = r.sum[i] / r.weight[i]
+				} else {
+					values[i] = default_no_data
+				}
+			}
+			delete(rows, next)
+			if err := fn(next, values); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for y0 := 0; y0 < layout.height; y0 += layout.tileHeight {
+		y1 := min(y0+layout.tileHeight, layout.height)
+		wy0, wy1 := max(y0-ov, 0), min(y1+ov, layout.height)
+		for row := wy0; row < wy1; row++ {
+			if rows[row] == nil {
+				rows[row] = &tileRow{sum: make([]float64, layout.width), weight: make([]float64, layout.width)}
+			}
+		}
+
+		for x0 := 0; x0 < layout.width; x0 += layout.tileWidth {
+			x1 := min(x0+layout.tileWidth, layout.width)
+			wx0, wx1 := max(x0-ov, 0), min(x1+ov, layout.width)
+
+			var predictor Predictor
+			for row := wy0; row < wy1; row++ {
+				r := rows[row]
+				wy := featherWeight(row, wy0, wy1, layout.height, ov)
+				for col := wx0; col < wx1; col++ {
+					pt := layout.position(col, row)
+					v := math.NaN()
+					if p.inside(pt) {
+						if predictor == nil {
+							var err error
+							if predictor, err = tile(wx0, wy0, wx1, wy1); err != nil {
+								return err
+							}
+						}
+						v = predictor.Predict(pt[0], pt[1])
+					}
+					if math.IsNaN(v) {
+						v = outside(pt[0], pt[1])
+					}
+					w := wy * featherWeight(col, wx0, wx1, layout.width, ov)
+					r.sum[col] += w * v
+					r.weight[col] += w
+				}
+			}
+		}
+
+		if err := flush(y1 - ov); err != nil {
+			return err
+		}
+	}
+	return flush(layout.height)
+}
+
+func tileKriging(global *Kriging, idx *pointIndex, layout *tileLayout, x0, y0, x1, y1 int) (*Kriging, error) {
+	buffer := float64(max(layout.overlap, 1))
+	lo, hi := layout.position(x0, y1-1), layout.position(x1-1, y0)
+	lo[0] -= buffer * layout.pixelSize[0]
+	lo[1] -= buffer * layout.pixelSize[1]
+	hi[0] += buffer * layout.pixelSize[0]
+	hi[1] += buffer * layout.pixelSize[1]
+
+	indices := idx.Range(lo, hi)
+	if len(indices) < default_tile_min_points {
+		seen := make(map[int]bool, len(indices))
+		for _, i := range indices {
+			seen[i] = true
+		}
+		centre := vec2d.T{(lo[0] + hi[0]) / 2, (lo[1] + hi[1]) / 2}
+		for _, nb := range idx.Nearest(centre[0], centre[1], default_tile_min_points, 0) {
+			if !seen[nb.index] {
+				indices = append(indices, nb.index)
+			}
+		}
+	}
+	if len(indices) == 0 {
+		return nil, errors.New("no points in tile window")
+	}
+	return global.local(indices).solve(0)
+}
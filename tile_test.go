@@ -0,0 +1,141 @@
+package kriging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/flywave/go-cog"
+	"github.com/hhrutter/lzw"
+
+	vec2d "github.com/flywave/go3d/float64/vec2"
+	vec3d "github.com/flywave/go3d/float64/vec3"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKrigeTiles(t *testing.T) {
+	a := assert.New(t)
+
+	pos := krigingPoints()
+	p := &KrigingInterpolator{inputPos: pos, model: Exponential, method: KrigingMethod, boundary: NewConvex(pos)}
+
+	global := New(pos)
+	_, err := global.Train(Exponential, 0, 100)
+	a.Nil(err)
+
+	for _, size := range [][3]int{{64, 64, 0}, {8, 8, 4}, {10, 7, 2}} {
+		layout := &tileLayout{width: 36, height: 36, tileWidth: size[0], tileHeight: size[1], overlap: size[2], pixelSize: [2]float64{2, 2}}
+		a.Equal(vec2d.Rect{Min: vec2d.T{0, 0}, Max: vec2d.T{70, 70}}, layout.Rect())
+
+		tile, err := p.tilePredictor(layout)
+		a.Nil(err)
+
+		next := 0
+		err = p.resampleTiles(layout, tile, func(row int, values []float64) error {
+			a.Equal(next, row)
+			next++
+			a.Len(values, layout.width)
+			for col, v := range values {
+				pt := layout.position(col, row)
+				if !p.boundary.InHull(vec3d.Zero, zRotator(), pt) {
+					a.Equal(default_no_data, v)
+					continue
+				}
+				if size[0] >= layout.width {
+					a.InDelta(global.Predict(pt[0], pt[1]), v, 1e-6)
+				} else {
+					a.InDelta(global.Predict(pt[0], pt[1]), v, 1)
+				}
+			}
+			return nil
+		})
+		a.Nil(err)
+		a.Equal(layout.height, next)
+	}
+}
+
+func TestTiledWriter(t *testing.T) {
+	a := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "tiled.tif")
+	f, err := os.Create(path)
+	a.Nil(err)
+
+	width, height := 600, 300
+	w, err := newTiledWriter(f, width, height, 256, -9999)
+	a.Nil(err)
+	a.NotNil(w.WriteRow(1, make([]float64, width)))
+	for row := 0; row < height; row++ {
+		values := make([]float64, width)
+		for col := range values {
+			values[col] = float64(row*width + col)
+		}
+		a.Nil(w.WriteRow(row, values))
+	}
+	ifd := &cog.IFD{NoData: "-9999"}
+	a.Nil(ifd.SetEPSG(4326, true))
+	a.Nil(w.Close(vec2d.Rect{Max: vec2d.T{6, 3}}, ifd))
+	a.Nil(f.Close())
+
+	data, err := os.ReadFile(path)
+	a.Nil(err)
+	le := binary.LittleEndian
+	a.Equal("II", string(data[:2]))
+	start := le.Uint32(data[4:])
+	n := int(le.Uint16(data[start:]))
+
+	tags := make(map[uint16][]uint32)
+	for i := 0; i < n; i++ {
+		e := data[int(start)+2+12*i:]
+		tag, typ, count := le.Uint16(e), le.Uint16(e[2:]), int(le.Uint32(e[4:]))
+		if typ != 3 && typ != 4 {
+			continue
+		}
+		size := map[uint16]int{3: 2, 4: 4}[typ]
+		raw := e[8:12]
+		if count*size > 4 {
+			raw = data[le.Uint32(e[8:]):]
+		}
+		for j := 0; j < count; j++ {
+			if typ == 3 {
+				tags[tag] = append(tags[tag], uint32(le.Uint16(raw[j*2:])))
+			} else {
+				tags[tag] = append(tags[tag], le.Uint32(raw[j*4:]))
+			}
+		}
+	}
+	a.Equal([]uint32{600}, tags[256])
+	a.Equal([]uint32{300}, tags[257])
+	a.Equal([]uint32{256}, tags[322])
+	a.Equal([]uint32{256}, tags[323])
+
+	offsets, counts := tags[324], tags[325]
+	a.Len(offsets, 6)
+	a.Len(counts, 6)
+	a.Equal(uint32(8), offsets[0])
+	for i := 1; i < len(offsets); i++ {
+		a.Equal(offsets[i-1]+counts[i-1], offsets[i])
+	}
+
+	last := data[offsets[5] : offsets[5]+counts[5]]
+	raw, err := io.ReadAll(lzw.NewReader(bytes.NewReader(last), true))
+	a.Nil(err)
+	tile := make([]float64, 256*256)
+	a.Nil(binary.Read(bytes.NewReader(raw), le, tile))
+	a.Equal(float64(256*width+512), tile[0])
+	a.Equal(float64(299*width+599), tile[43*256+87])
+	a.Equal(-9999.0, tile[43*256+88])
+	a.Equal(-9999.0, tile[44*256])
+}
+
+func TestTiledSimulation(t *testing.T) {
+	a := assert.New(t)
+
+	p := NewKrigingInterpolator(Options{TileSize: &[2]uint32{64, 64}, Simulation: &SimulationOptions{}})
+	_, _, err := p.Process()
+	a.EqualError(err, "tiled output is not supported for simulation")
+}
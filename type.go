@@ -8,7 +8,21 @@ const (
 	Spherical   ModelType = "spherical"
 )
 
-type DistanceList [][2]float64
+type MethodType string
+
+const (
+	KrigingMethod MethodType = "kriging"
+	IDWMethod     MethodType = "idw"
+	RBFMethod     MethodType = "rbf"
+	TINMethod     MethodType = "tin"
+	NaturalMethod MethodType = "natural"
+)
+
+type Predictor interface {
+	Predict(x, y float64) float64
+}
+
+type DistanceList [][3]float64
 
 func (t DistanceList) Len() int {
 	return len(t)
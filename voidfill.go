@@ -0,0 +1,241 @@
+package kriging
+
+import (
+	"errors"
+	"image"
+	"math"
+	"strconv"
+
+	"github.com/flywave/go-cog"
+	"github.com/flywave/go-geo"
+
+	vec2d "github.com/flywave/go3d/float64/vec2"
+	vec3d "github.com/flywave/go3d/float64/vec3"
+)
+
+const (
+	default_void_margin     = 8
+	default_void_neighbours = 32
+)
+
+type VoidFillOptions struct {
+	Margin     int
+	Neighbours int
+}
+
+func rasterValues(data interface{}) []float64 {
+	convert := func(n int, at func(i int) float64) []float64 {
+		ret := make([]float64, n)
+		for i := range ret {
+			ret[i] = at(i)
+		}
+		return ret
+	}
+	switch d := data.(type) {
+	case []float64:
+		return d
+	case []float32:
+		return convert(len(d), func(i int) float64 { return float64(d[i]) })
+	case []int16:
+		return convert(len(d), func(i int) float64 { return float64(d[i]) })
+	case []int32:
+		return convert(len(d), func(i int) float64 { return float64(d[i]) })
+	case []int64:
+		return convert(len(d), func(i int) float64 { return float64(d[i]) })
+	case []uint16:
+		return convert(len(d), func(i int) float64 { return float64(d[i]) })
+	case []uint32:
+		return convert(len(d), func(i int) float64 { return float64(d[i]) })
+	case []uint64:
+		return convert(len(d), func(i int) float64 { return float64(d[i]) })
+	}
+	return nil
+}
+
+func rasterData(values []float64, like interface{}) interface{} {
+	switch like.(type) {
+	case []float32:
+		ret := make([]float32, len(values))
+		for i, v := range values {
+			ret[i] = float32(v)
+		}
+		return ret
+	case []int16:
+		ret := make([]int16, len(values))
+		for i, v := range values {
+			ret[i] = int16(math.Round(v))
+		}
+		return ret
+	case []int32:
+		ret := make([]int32, len(values))
+		for i, v := range values {
+			ret[i] = int32(math.Round(v))
+		}
+		return ret
+	case []int64:
+		ret := make([]int64, len(values))
+		for i, v := range values {
+			ret[i] = int64(math.Round(v))
+		}
+		return ret
+	case []uint16:
+		ret := make([]uint16, len(values))
+		for i, v := range values {
+			ret[i] = uint16(math.Round(v))
+		}
+		return ret
+	case []uint32:
+		ret := make([]uint32, len(values))
+		for i, v := range values {
+			ret[i] = uint32(math.Round(v))
+		}
+		return ret
+	case []uint64:
+		ret := make([]uint64, len(values))
+		for i, v := range values {
+			ret[i] = uint64(math.Round(v))
+		}
+		return ret
+	}
+	return values
+}
+
+func voidRegions(values []float64, width, height int, void func(v float64) bool) [][]int {
+	label := make([]bool, len(values))
+	regions := [][]int{}
+	for start := range values {
+		if label[start] || !void(values[start]) {
+			continue
+		}
+		label[start] = true
+		region := []int{start}
+		for k := 0; k < len(region); k++ {
+			x, y := region[k]%width, region[k]/width
+			for _, d := range [4][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+				nx, ny := x+d[0], y+d[1]
+				if nx < 0 || ny < 0 || nx >= width || ny >= height {
+					continue
+				}
+				if i := ny*width + nx; !label[i] && void(values[i]) {
+					label[i] = true
+					region = append(region, i)
+				}
+			}
+		}
+		regions = append(regions, region)
+	}
+	return regions
+}
+
+func voidObservations(values []float64, width, height int, region []int, margin int, void func(v float64) bool) []int {
+	depth := make(map[int]int, len(region))
+	for _, i := range region {
+		depth[i] = 0
+	}
+	queue := append([]int{}, region...)
+	obs := []int{}
+	for k := 0; k < len(queue); k++ {
+		i := queue[k]
+		if depth[i] >= margin {
+			continue
+		}
+		x, y := i%width, i/width
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				nx, ny := x+dx, y+dy
+				if nx < 0 || ny < 0 || nx >= width || ny >= height {
+					continue
+				}
+				j := ny*width + nx
+				if _, ok := depth[j]; ok || void(values[j]) {
+					continue
+				}
+				depth[j] = depth[i] + 1
+				queue = append(queue, j)
+				obs = append(obs, j)
+			}
+		}
+	}
+	return obs
+}
+
+func (p *KrigingInterpolator) fillVoids(values []float64, width, height int, origin vec2d.T, ps [2]float64, nodata float64) []float64 {
+	void := func(v float64) bool {
+		return math.IsNaN(v) || v == nodata
+	}
+	position := func(i int) vec3d.T {
+		x, y := i%width, i/width
+		return vec3d.T{origin[0] + (float64(x)+0.5)*ps[0], origin[1] - (float64(y)+0.5)*ps[1], values[i]}
+	}
+
+	margin, k := p.voidFill.Margin, p.voidFill.Neighbours
+	if margin <= 0 {
+		margin = default_void_margin
+	}
+	if k <= 0 {
+		k = default_void_neighbours
+	}
+
+	filled := append([]float64{}, values...)
+	for _, region := range voidRegions(values, width, height, void) {
+		obs := voidObservations(values, width, height, region, margin, void)
+		if len(obs) == 0 {
+			continue
+		}
+		pos := make([]vec3d.T, len(obs))
+		for i, o := range obs {
+			pos[i] = position(o)
+		}
+
+		var predict func(x, y float64) float64
+		global := New(pos)
+		if len(pos) >= 3 && global.fitVariogram(p.model, 100) == nil {
+			predict = global.localPredictor(k, nil).Predict
+		}
+		idw, _ := NewIDW(pos, IDWOptions{MaxNeighbours: k})
+
+		for _, i := range region {
+			pt := position(i)
+			v := math.NaN()
+			if predict != nil {
+				v = predict(pt[0], pt[1])
+			}
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				v = idw.Predict(pt[0], pt[1])
+			}
+			filled[i] = v
+		}
+	}
+	return filled
+}
+
+func (p *KrigingInterpolator) processVoidFill() (vec2d.Rect, geo.Proj, error) {
+	if p.background == nil {
+		return vec2d.Rect{}, nil, errors.New("void fill requires a background raster")
+	}
+	values := p.backgroundValues()
+	if values == nil {
+		return vec2d.Rect{}, nil, errors.New("unsupported raster sample type")
+	}
+
+	si := p.background.GetSize(0)
+	bounds := p.background.GetBounds(0)
+	epsgcode, err := p.background.GetEPSGCode(0)
+	if err != nil {
+		return vec2d.Rect{}, nil, err
+	}
+	nodata := float64(default_no_data)
+	if nd := p.background.GetNoData(0); nd != nil {
+		nodata = *nd
+	}
+
+	width, height := int(si[0]), int(si[1])
+	ps := [2]float64{(bounds.Max[0] - bounds.Min[0]) / float64(width), (bounds.Max[1] - bounds.Min[1]) / float64(height)}
+	filled := p.fillVoids(values, width, height, vec2d.T{bounds.Min[0], bounds.Max[1]}, ps, nodata)
+
+	srs := geo.NewProj(epsgcode)
+	rect := image.Rect(0, 0, width, height)
+	nodataStr := strconv.FormatFloat(nodata, 'f', -1, 64)
+	src := cog.NewSource(rasterData(filled, p.background.Data[0]), &rect, cog.CTLZW)
+	return bounds, srs, cog.WriteTile(p.output, src, bounds, srs, si, &nodataStr)
+}
@@ -0,0 +1,50 @@
+package kriging
+
+import (
+	"math"
+	"testing"
+
+	vec2d "github.com/flywave/go3d/float64/vec2"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFillVoids(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal([]float64{1, -2, 3}, rasterValues([]int16{1, -2, 3}))
+	a.Nil(rasterValues([]byte{1}))
+	a.Equal([]int16{1, -2, 4}, rasterData([]float64{1, -2, 3.6}, []int16{}))
+	a.Equal([]float32{1.5}, rasterData([]float64{1.5}, []float32{}))
+	a.Equal([]float64{1.5}, rasterData([]float64{1.5}, []float64{}))
+
+	width, height := 30, 30
+	plane := func(x, y int) float64 {
+		return 100 + 2*float64(x) - 3*float64(y)
+	}
+	values := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			values[y*width+x] = plane(x, y)
+			if x >= 10 && x < 16 && y >= 12 && y < 17 {
+				values[y*width+x] = default_no_data
+			}
+		}
+	}
+	values[3*width+25] = math.NaN()
+	regions := voidRegions(values, width, height, func(v float64) bool { return math.IsNaN(v) || v == default_no_data })
+	a.Len(regions, 2)
+
+	p := NewKrigingInterpolator(Options{VoidFill: &VoidFillOptions{Margin: 4}})
+	p.model = Exponential
+	filled := p.fillVoids(values, width, height, vec2d.T{0, float64(height)}, [2]float64{1, 1}, default_no_data)
+	a.Equal(default_no_data, values[12*width+10])
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			a.InDelta(plane(x, y), filled[y*width+x], 1, "%d %d", x, y)
+		}
+	}
+
+	empty := []float64{default_no_data, default_no_data, default_no_data, default_no_data}
+	a.Equal(empty, p.fillVoids(empty, 2, 2, vec2d.T{0, 2}, [2]float64{1, 1}, default_no_data))
+}
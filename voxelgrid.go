@@ -2,22 +2,36 @@ package kriging
 
 import (
 	"errors"
+	"math"
+	"sort"
 
 	vec3d "github.com/flywave/go3d/float64/vec3"
 )
 
-type voxelGrid struct {
-	LeafSize vec3d.T
+type VoxelReducer string
+
+const (
+	MeanReducer     VoxelReducer = "mean"
+	MedianReducer   VoxelReducer = "median"
+	MinReducer      VoxelReducer = "min"
+	MaxReducer      VoxelReducer = "max"
+	CentroidReducer VoxelReducer = "centroid"
+)
+
+type VoxelFilterOptions struct {
+	Disabled   bool
+	Reducer    VoxelReducer
+	LeafSize   float64
+	LeafHeight float64
 }
 
-type voxel struct {
-	sum   vec3d.T
-	num   int
-	index int
+type voxelGrid struct {
+	LeafSize vec3d.T
+	Reducer  VoxelReducer
 }
 
 func newVoxelGrid(leafSize vec3d.T) *voxelGrid {
-	vg := &voxelGrid{LeafSize: leafSize}
+	vg := &voxelGrid{LeafSize: leafSize, Reducer: MeanReducer}
 	return vg
 }
 
@@ -47,47 +61,134 @@ func MulFloat(vec *vec3d.T, v float64) *vec3d.T {
 	return vec
 }
 
-func (f *voxelGrid) Filter(pc []vec3d.T) ([]vec3d.T, error) {
-	min, max, err := minMaxVec3(pc)
+func (f *voxelGrid) voxelize(pc []vec3d.T) ([][]int, error) {
+	min, _, err := minMaxVec3(pc)
 	if err != nil {
 		return nil, err
 	}
 
-	size := max.Sub(&min)
-	xs, ys := int(size[0]/f.LeafSize[0]), int(size[1]/f.LeafSize[1])
-	zs := 0
-	if f.LeafSize[2] > 0 {
-		zs = int(size[2] / f.LeafSize[2])
+	cell := func(v, leaf float64) int {
+		if leaf <= 0 {
+			return 0
+		}
+		return int(math.Floor(v / leaf))
 	}
-	voxels := make([]voxel, (xs+1)*(ys+1)*(zs+1))
 
-	var n int
+	voxels := make(map[[3]int]int)
+	groups := [][]int{}
 	for i := range pc {
-		p := pc[i].Sub(&min)
-		x, y, z := int(p[0]/f.LeafSize[0]), int(p[1]/f.LeafSize[1]), int(p[2]/f.LeafSize[2])
-		v := &voxels[x+xs*(y+ys*z)]
-		if v.num == 0 {
-			v.index = i
-			n++
+		p := vec3d.Sub(&pc[i], &min)
+		key := [3]int{cell(p[0], f.LeafSize[0]), cell(p[1], f.LeafSize[1]), cell(p[2], f.LeafSize[2])}
+		g, ok := voxels[key]
+		if !ok {
+			g = len(groups)
+			voxels[key] = g
+			groups = append(groups, nil)
 		}
-		v.num++
-		v.sum.Add(p)
-		i++
+		groups[g] = append(groups[g], i)
+	}
+	return groups, nil
+}
+
+func (f *voxelGrid) reduce(pc []vec3d.T, indices []int) (vec3d.T, int) {
+	if len(indices) == 1 {
+		return pc[indices[0]], indices[0]
 	}
 
-	newPc := make([]vec3d.T, 0, len(pc))
-	for i := range voxels {
-		v := &voxels[i]
-		if n := v.num; n > 0 {
-			if n > 1 {
-				f := MulFloat(&v.sum, 1.0/float64(n))
-				f.Add(&min)
-				newPc = append(newPc, *f)
-			} else {
-				newPc = append(newPc, *pc[v.index].Add(&min))
+	switch f.Reducer {
+	case MinReducer, MaxReducer:
+		best := indices[0]
+		for _, i := range indices[1:] {
+			if (f.Reducer == MinReducer && pc[i][2] < pc[best][2]) || (f.Reducer == MaxReducer && pc[i][2] > pc[best][2]) {
+				best = i
+			}
+		}
+		return pc[best], best
+	case MedianReducer:
+		sorted := append([]int{}, indices...)
+		sort.SliceStable(sorted, func(a, b int) bool {
+			return pc[sorted[a]][2] < pc[sorted[b]][2]
+		})
+		best := sorted[(len(sorted)-1)/2]
+		return pc[best], best
+	case CentroidReducer:
+		centre := meanVec3(pc, indices)
+		best, dist := -1, math.Inf(1)
+		for _, i := range indices {
+			if d := math.Hypot(pc[i][0]-centre[0], pc[i][1]-centre[1]); d < dist {
+				best, dist = i, d
 			}
 		}
+		return pc[best], best
+	}
+	return meanVec3(pc, indices), -1
+}
+
+func (f *voxelGrid) Filter(pc []vec3d.T) ([]vec3d.T, error) {
+	groups, err := f.voxelize(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	newPc := make([]vec3d.T, 0, len(groups))
+	for _, g := range groups {
+		p, _ := f.reduce(pc, g)
+		newPc = append(newPc, p)
 	}
 
 	return newPc, nil
 }
+
+func (f *voxelGrid) FilterVariance(pc []vec3d.T, variance []float64) ([]vec3d.T, []float64, error) {
+	groups, err := f.voxelize(pc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	newPc := make([]vec3d.T, 0, len(groups))
+	newVar := make([]float64, 0, len(groups))
+	for _, g := range groups {
+		p, i := f.reduce(pc, g)
+		newPc = append(newPc, p)
+		if i >= 0 {
+			newVar = append(newVar, variance[i])
+			continue
+		}
+		sum := 0.0
+		for _, i := range g {
+			sum += variance[i]
+		}
+		newVar = append(newVar, sum/float64(len(g)*len(g)))
+	}
+
+	return newPc, newVar, nil
+}
+
+func (f *voxelGrid) FilterWeights(pc []vec3d.T, weights []float64) ([]float64, error) {
+	groups, err := f.voxelize(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	newWeights := make([]float64, 0, len(groups))
+	for _, g := range groups {
+		sum := 0.0
+		for _, i := range g {
+			sum += weights[i]
+		}
+		newWeights = append(newWeights, sum)
+	}
+
+	return newWeights, nil
+}
+
+func meanVec3(pc []vec3d.T, indices []int) vec3d.T {
+	if len(indices) == 1 {
+		return pc[indices[0]]
+	}
+	var sum vec3d.T
+	for _, i := range indices {
+		sum.Add(&pc[i])
+	}
+	return *MulFloat(&sum, 1.0/float64(len(indices)))
+}
@@ -0,0 +1,74 @@
+package kriging
+
+import (
+	"testing"
+
+	vec3d "github.com/flywave/go3d/float64/vec3"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVoxelGridReducers(t *testing.T) {
+	a := assert.New(t)
+
+	pc := []vec3d.T{{0.1, 0.1, 10}, {0.9, 0.9, 2}, {0.4, 0.5, 4}, {0.2, 0.8, 30}, {2.5, 0.5, 7}}
+	variance := []float64{1, 2, 3, 4, 5}
+
+	expect := map[VoxelReducer]vec3d.T{
+		MeanReducer:     {0.4, 0.575, 11.5},
+		MedianReducer:   pc[2],
+		MinReducer:      pc[1],
+		MaxReducer:      pc[3],
+		CentroidReducer: pc[2],
+	}
+	for reducer, v := range expect {
+		vg := newVoxelGrid(vec3d.T{1, 1, 0})
+		vg.Reducer = reducer
+		res, err := vg.Filter(pc)
+		a.Nil(err)
+		a.Len(res, 2)
+		for i := range v {
+			a.InDelta(v[i], res[0][i], 1e-9, string(reducer))
+		}
+		a.Equal(pc[4], res[1])
+	}
+
+	vg := newVoxelGrid(vec3d.T{1, 1, 0})
+	vg.Reducer = MinReducer
+	_, newVar, err := vg.FilterVariance(pc, variance)
+	a.Nil(err)
+	a.Equal([]float64{2, 5}, newVar)
+
+	vg.Reducer = MeanReducer
+	_, newVar, err = vg.FilterVariance(pc, variance)
+	a.Nil(err)
+	a.InDelta(10.0/16, newVar[0], 1e-9)
+}
+
+func TestVoxelGridIndex(t *testing.T) {
+	a := assert.New(t)
+
+	vg := newVoxelGrid(vec3d.T{1, 1, 1})
+	groups, err := vg.voxelize([]vec3d.T{{0, 0, 0}, {2, 0, 0}, {0, 1, 0}, {0, 0, 1}, {0.5, 0.5, 0.5}})
+	a.Nil(err)
+	a.Equal([][]int{{0, 4}, {1}, {2}, {3}}, groups)
+
+	_, err = vg.voxelize(nil)
+	a.NotNil(err)
+}
+
+func TestVoxelFilterOptions(t *testing.T) {
+	a := assert.New(t)
+
+	pos := []vec3d.T{{10, 45, 100}, {10.00001, 45.00001, 130}, {10.001, 45, 90}}
+
+	p := NewKrigingInterpolator(Options{Filter: &VoxelFilterOptions{Disabled: true}})
+	res, _, err := p.filter(pos, nil)
+	a.Nil(err)
+	a.Equal(pos, res)
+
+	p = NewKrigingInterpolator(Options{Filter: &VoxelFilterOptions{LeafSize: 10, Reducer: MinReducer}})
+	res, _, err = p.filter(pos, nil)
+	a.Nil(err)
+	a.Equal([]vec3d.T{pos[0], pos[2]}, res)
+}